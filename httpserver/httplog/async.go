@@ -0,0 +1,129 @@
+package httplog
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	mylog "github.com/romapres2010/httpserver/log"
+)
+
+// OverflowPolicy - политика поведения асинхронного writer-а при переполнении
+// буфера Config.AsyncBufferSize
+type OverflowPolicy string
+
+const (
+	OverflowBlock      OverflowPolicy = "block"      // ждать освобождения места в буфере (по умолчанию)
+	OverflowDrop       OverflowPolicy = "drop"        // отбросить новую запись
+	OverflowDropOldest OverflowPolicy = "drop_oldest" // отбросить самую старую запись из буфера и положить новую
+)
+
+// startAsyncWriter - запускает фоновую горутину, вычитывающую log.entryCh и
+// пишущую записи в log.sink. Вызывается из NewLogger, если задан
+// cfg.AsyncBufferSize
+func (log *Logger) startAsyncWriter(bufferSize int) {
+	log.entryCh = make(chan Entry, bufferSize)
+
+	log.wg.Add(1)
+	go func() {
+		defer log.wg.Done()
+		for entry := range log.entryCh {
+			if err := log.sink.Write(entry); err != nil {
+				mylog.PrintfErrorStd(fmt.Sprintf("Error write HTTP log entry: %+v", err))
+			}
+		}
+	}()
+}
+
+// writeEntry - пишет запись в sink синхронно, либо, если включен асинхронный
+// режим (log.entryCh != nil), помещает ее в буферизированный канал с учетом
+// cfg.OnFull. closeMu.RLock гарантирует, что Close не закроет entryCh, пока
+// эта запись отправляется в него - иначе отправка в уже закрытый канал
+// паникует
+func (log *Logger) writeEntry(entry Entry) error {
+	if log.entryCh == nil {
+		return log.sink.Write(entry)
+	}
+
+	log.closeMu.RLock()
+	defer log.closeMu.RUnlock()
+	if log.closed {
+		return nil
+	}
+
+	switch log.cfg.OnFull {
+	case OverflowDrop:
+		select {
+		case log.entryCh <- entry:
+		default:
+			atomic.AddUint64(&log.droppedCount, 1)
+		}
+	case OverflowDropOldest:
+		select {
+		case log.entryCh <- entry:
+		default:
+			select {
+			case <-log.entryCh:
+				atomic.AddUint64(&log.droppedCount, 1)
+			default:
+			}
+			select {
+			case log.entryCh <- entry:
+			default:
+				atomic.AddUint64(&log.droppedCount, 1)
+			}
+		}
+	default: // OverflowBlock и любое неизвестное значение - ждем место в буфере
+		log.entryCh <- entry
+	}
+
+	return nil
+}
+
+// Close - закрывает Logger: если был включен асинхронный writer, ожидает
+// дренирования оставшихся записей из буфера, но не дольше ctx, затем
+// закрывает sink и ротируемый writer
+// =====================================================================
+func (log *Logger) Close(ctx context.Context) {
+	if log.entryCh != nil {
+		// дожидаемся, пока все writeEntry, уже держащие closeMu.RLock, закончат
+		// отправку, затем помечаем Logger закрытым и закрываем канал - после
+		// этого writeEntry видит closed и не пытается писать в entryCh
+		log.closeMu.Lock()
+		log.closed = true
+		close(log.entryCh)
+		log.closeMu.Unlock()
+
+		drained := make(chan struct{})
+		go func() {
+			log.wg.Wait()
+			close(drained)
+		}()
+
+		select {
+		case <-drained:
+		case <-ctx.Done():
+			mylog.PrintfErrorStd(fmt.Sprintf("Close: context deadline exceeded, %d entries may be lost", log.pendingAsyncCount()))
+		}
+	}
+
+	if dropped := atomic.LoadUint64(&log.droppedCount); dropped > 0 {
+		mylog.PrintfErrorStd(fmt.Sprintf("Close: %d HTTP log entries were dropped due to buffer overflow", dropped))
+	}
+
+	if log.sink != nil {
+		_ = log.sink.Close()
+	}
+	if log.writer != nil {
+		_ = log.writer.Close()
+	}
+}
+
+// pendingAsyncCount - приблизительное число записей, еще не вычитанных из
+// буфера асинхронного writer-а
+func (log *Logger) pendingAsyncCount() int {
+	if log.entryCh == nil {
+		return 0
+	}
+	return len(log.entryCh)
+}
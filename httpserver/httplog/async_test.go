@@ -0,0 +1,237 @@
+package httplog
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingSink - Sink, сохраняющий все записи для последующей проверки
+type recordingSink struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (s *recordingSink) Write(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func (s *recordingSink) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+func (s *recordingSink) snapshot() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Entry, len(s.entries))
+	copy(out, s.entries)
+	return out
+}
+
+// blockingSink - Sink, блокирующий Write до получения сигнала unblock; нужен,
+// чтобы удерживать фоновую горутину занятой и надежно наполнить entryCh.
+// started сигнализирует о том, что первая запись уже вычитана из entryCh и
+// Write на ней начал блокироваться, чтобы тест не гонялся с воркером за
+// буфер канала
+type blockingSink struct {
+	recordingSink
+	unblock chan struct{}
+	started chan struct{}
+}
+
+func (s *blockingSink) Write(entry Entry) error {
+	select {
+	case s.started <- struct{}{}:
+	default:
+	}
+	<-s.unblock
+	return s.recordingSink.Write(entry)
+}
+
+func TestWriteEntrySyncWhenAsyncDisabled(t *testing.T) {
+	sink := &recordingSink{}
+	log := &Logger{cfg: &Config{}, sink: sink}
+
+	if err := log.writeEntry(Entry{ReqID: 1}); err != nil {
+		t.Fatalf("writeEntry() error = %v", err)
+	}
+	if sink.len() != 1 {
+		t.Errorf("sink got %d entries, want 1 (synchronous write)", sink.len())
+	}
+}
+
+func TestWriteEntryBlockWaitsForSpace(t *testing.T) {
+	sink := &blockingSink{unblock: make(chan struct{}), started: make(chan struct{}, 1)}
+	log := &Logger{cfg: &Config{OnFull: OverflowBlock}, sink: sink}
+	log.startAsyncWriter(1)
+
+	// первая запись уходит в воркер и блокируется там, вторая заполняет буфер
+	if err := log.writeEntry(Entry{ReqID: 1}); err != nil {
+		t.Fatalf("writeEntry() error = %v", err)
+	}
+	<-sink.started // ждем, пока воркер вычитает запись 1 из канала и начнет блокироваться в Write
+	if err := log.writeEntry(Entry{ReqID: 2}); err != nil {
+		t.Fatalf("writeEntry() error = %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		_ = log.writeEntry(Entry{ReqID: 3})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("writeEntry() with OverflowBlock did not block on a full buffer")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(sink.unblock)
+	<-done
+	log.Close(context.Background())
+}
+
+func TestWriteEntryDropOnFull(t *testing.T) {
+	sink := &blockingSink{unblock: make(chan struct{}), started: make(chan struct{}, 1)}
+	log := &Logger{cfg: &Config{OnFull: OverflowDrop}, sink: sink}
+	log.startAsyncWriter(1)
+
+	if err := log.writeEntry(Entry{ReqID: 1}); err != nil { // consumed by the blocked worker
+		t.Fatalf("writeEntry() error = %v", err)
+	}
+	<-sink.started
+	if err := log.writeEntry(Entry{ReqID: 2}); err != nil { // fills the buffer
+		t.Fatalf("writeEntry() error = %v", err)
+	}
+	if err := log.writeEntry(Entry{ReqID: 3}); err != nil { // must be dropped, not block
+		t.Fatalf("writeEntry() error = %v", err)
+	}
+
+	if got := atomic.LoadUint64(&log.droppedCount); got != 1 {
+		t.Errorf("droppedCount = %d, want 1", got)
+	}
+
+	close(sink.unblock)
+	log.Close(context.Background())
+}
+
+func TestWriteEntryDropOldestOnFull(t *testing.T) {
+	sink := &blockingSink{unblock: make(chan struct{}), started: make(chan struct{}, 1)}
+	log := &Logger{cfg: &Config{OnFull: OverflowDropOldest}, sink: sink}
+	log.startAsyncWriter(1)
+
+	if err := log.writeEntry(Entry{ReqID: 1}); err != nil { // consumed by the blocked worker
+		t.Fatalf("writeEntry() error = %v", err)
+	}
+	<-sink.started
+	if err := log.writeEntry(Entry{ReqID: 2}); err != nil { // fills the buffer
+		t.Fatalf("writeEntry() error = %v", err)
+	}
+	if err := log.writeEntry(Entry{ReqID: 3}); err != nil { // evicts ReqID 2, keeps ReqID 3
+		t.Fatalf("writeEntry() error = %v", err)
+	}
+
+	if got := atomic.LoadUint64(&log.droppedCount); got != 1 {
+		t.Errorf("droppedCount = %d, want 1", got)
+	}
+
+	close(sink.unblock)
+
+	deadline := time.After(time.Second)
+	for sink.len() < 2 {
+		select {
+		case <-deadline:
+			t.Fatal("worker did not drain remaining entries in time")
+		default:
+		}
+	}
+
+	entries := sink.snapshot()
+	if entries[len(entries)-1].ReqID != 3 {
+		t.Errorf("last written entry ReqID = %d, want 3 (newest entry survives drop_oldest)", entries[len(entries)-1].ReqID)
+	}
+
+	log.Close(context.Background())
+}
+
+func TestCloseDrainsPendingEntries(t *testing.T) {
+	sink := &recordingSink{}
+	log := &Logger{cfg: &Config{}, sink: sink}
+	log.startAsyncWriter(10)
+
+	for i := uint64(0); i < 5; i++ {
+		if err := log.writeEntry(Entry{ReqID: i}); err != nil {
+			t.Fatalf("writeEntry() error = %v", err)
+		}
+	}
+
+	log.Close(context.Background())
+
+	if sink.len() != 5 {
+		t.Errorf("sink got %d entries after Close, want 5", sink.len())
+	}
+}
+
+func TestWriteEntryAfterCloseDoesNotPanic(t *testing.T) {
+	sink := &recordingSink{}
+	log := &Logger{cfg: &Config{}, sink: sink}
+	log.startAsyncWriter(1)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = log.writeEntry(Entry{ReqID: 1})
+			}
+		}
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+	log.Close(context.Background())
+	close(stop)
+	wg.Wait() // не должно паниковать "send on closed channel"
+}
+
+func TestCloseReturnsOnContextDeadline(t *testing.T) {
+	sink := &blockingSink{unblock: make(chan struct{})}
+	log := &Logger{cfg: &Config{}, sink: sink}
+	log.startAsyncWriter(1)
+
+	if err := log.writeEntry(Entry{ReqID: 1}); err != nil {
+		t.Fatalf("writeEntry() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	log.Close(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close() took %v, want to return promptly once ctx is done", elapsed)
+	}
+
+	close(sink.unblock)
+}
+
+func TestPendingAsyncCountSyncMode(t *testing.T) {
+	log := &Logger{cfg: &Config{}, sink: &recordingSink{}}
+	if got := log.pendingAsyncCount(); got != 0 {
+		t.Errorf("pendingAsyncCount() = %d, want 0 when async is disabled", got)
+	}
+}
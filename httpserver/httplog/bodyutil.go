@@ -0,0 +1,85 @@
+package httplog
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+// readRequestBody - читает тело запроса и восстанавливает req.Body, чтобы
+// оно осталось доступным для дальнейшей обработки запроса
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req == nil || req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	_ = req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// readResponseBody - читает тело ответа и восстанавливает resp.Body, чтобы
+// оно осталось доступным для вызывающей стороны
+func readResponseBody(resp *http.Response) ([]byte, error) {
+	if resp == nil || resp.Body == nil {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// requestBodyForLog - готовит тело запроса для LogHTTP*Request. Для бинарных
+// Content-Type (image/*, application/octet-stream) formatBody все равно
+// отбрасывает прочитанное тело ради плейсхолдера "Content-Type: X, Size: Y" -
+// поэтому для них тело не читается в память вовсе, размер берется из
+// Content-Length
+func requestBodyForLog(req *http.Request, cfg *Config) ([]byte, error) {
+	contentType := req.Header.Get("Content-Type")
+	if isBinaryMediaType(mediaTypeOf(contentType)) {
+		return binaryBodySummary(contentType, req.ContentLength), nil
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	return formatBody(contentType, body, cfg), nil
+}
+
+// responseBodyForLog - аналог requestBodyForLog для тела ответа
+func responseBodyForLog(resp *http.Response, cfg *Config) ([]byte, error) {
+	contentType := resp.Header.Get("Content-Type")
+	if isBinaryMediaType(mediaTypeOf(contentType)) {
+		return binaryBodySummary(contentType, resp.ContentLength), nil
+	}
+
+	body, err := readResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	return formatBody(contentType, body, cfg), nil
+}
+
+// binaryBodySummary - формирует плейсхолдер для бинарного тела без его
+// чтения в память; ContentLength == -1 означает, что размер заранее неизвестен
+func binaryBodySummary(contentType string, contentLength int64) []byte {
+	size := "unknown"
+	if contentLength >= 0 {
+		size = strconv.FormatInt(contentLength, 10)
+	}
+	return []byte(fmt.Sprintf("Content-Type: %s, Size: %s bytes", contentType, size))
+}
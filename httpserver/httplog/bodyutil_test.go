@@ -0,0 +1,111 @@
+package httplog
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadRequestBodyRestoresBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		t.Fatalf("readRequestBody() error = %v", err)
+	}
+	if string(body) != "hello" {
+		t.Fatalf("readRequestBody() = %q, want %q", body, "hello")
+	}
+
+	again, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("req.Body not readable after readRequestBody(): %v", err)
+	}
+	if string(again) != "hello" {
+		t.Errorf("req.Body after readRequestBody() = %q, want %q", again, "hello")
+	}
+}
+
+func TestReadRequestBodyNilBody(t *testing.T) {
+	if body, err := readRequestBody(nil); body != nil || err != nil {
+		t.Errorf("readRequestBody(nil) = (%v, %v), want (nil, nil)", body, err)
+	}
+}
+
+// failingBody - io.ReadCloser, который всегда возвращает ошибку при чтении;
+// используется, чтобы убедиться, что бинарные тела не читаются вовсе
+type failingBody struct{}
+
+func (failingBody) Read(p []byte) (int, error) { return 0, fmt.Errorf("should not be read") }
+func (failingBody) Close() error                { return nil }
+
+func TestRequestBodyForLogSkipsReadForBinaryContentType(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", failingBody{})
+	req.Header.Set("Content-Type", "image/png")
+	req.ContentLength = 1234
+
+	got, err := requestBodyForLog(req, &Config{})
+	if err != nil {
+		t.Fatalf("requestBodyForLog() error = %v", err)
+	}
+
+	want := "Content-Type: image/png, Size: 1234 bytes"
+	if string(got) != want {
+		t.Errorf("requestBodyForLog() = %q, want %q", got, want)
+	}
+}
+
+func TestRequestBodyForLogReadsTextContentType(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a":1}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	got, err := requestBodyForLog(req, &Config{})
+	if err != nil {
+		t.Fatalf("requestBodyForLog() error = %v", err)
+	}
+	if !strings.Contains(string(got), `"a": 1`) {
+		t.Errorf("requestBodyForLog() = %q, want pretty-printed JSON", got)
+	}
+}
+
+func TestResponseBodyForLogSkipsReadForBinaryContentType(t *testing.T) {
+	resp := &http.Response{
+		Header:        http.Header{"Content-Type": []string{"application/octet-stream"}},
+		Body:          failingBody{},
+		ContentLength: -1,
+	}
+
+	got, err := responseBodyForLog(resp, &Config{})
+	if err != nil {
+		t.Fatalf("responseBodyForLog() error = %v", err)
+	}
+
+	want := "Content-Type: application/octet-stream, Size: unknown bytes"
+	if string(got) != want {
+		t.Errorf("responseBodyForLog() = %q, want %q", got, want)
+	}
+}
+
+func TestBinaryBodySummary(t *testing.T) {
+	tests := []struct {
+		name          string
+		contentType   string
+		contentLength int64
+		want          string
+	}{
+		{"known size", "image/png", 42, "Content-Type: image/png, Size: 42 bytes"},
+		{"unknown size", "application/octet-stream", -1, "Content-Type: application/octet-stream, Size: unknown bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := binaryBodySummary(tt.contentType, tt.contentLength)
+			if string(got) != tt.want {
+				t.Errorf("binaryBodySummary(%q, %d) = %q, want %q", tt.contentType, tt.contentLength, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package httplog
+
+import "time"
+
+// Entry represent а одна структурная запись HTTP события - запрос или ответ,
+// входящий или исходящий
+type Entry struct {
+	Timestamp   time.Time         // время события
+	Direction   string            // "in_request", "in_response", "out_request", "out_response"
+	ReqID       uint64            // уникальный номер запроса, присвоенный Logger-ом
+	TraceID     string            // trace_id из context, если есть
+	SpanID      string            // span_id из context, если есть
+	Method      string            // HTTP метод
+	URL         string            // URL запроса
+	Status      int               // HTTP статус ответа, 0 для запроса
+	RemoteAddr  string            // адрес вызывающей стороны
+	DurationMS  int64             // длительность обработки в миллисекундах, 0 если неизвестна
+	ReqHeaders  map[string]string // заголовки запроса
+	RespHeaders map[string]string // заголовки ответа
+	ReqBody     []byte            // тело запроса
+	RespBody    []byte            // тело ответа
+}
+
+// Sink represent получатель структурных записей логирования HTTP событий
+type Sink interface {
+	// Write записывает одну запись в получатель
+	Write(entry Entry) error
+
+	// Close освобождает ресурсы получателя
+	Close() error
+}
@@ -3,13 +3,14 @@ package httplog
 import (
 	"context"
 	"fmt"
-	"os"
+	"io"
+	"io/ioutil"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"net/http"
-	"net/http/httputil"
 
 	myerror "github.com/romapres2010/httpserver/error"
 	mylog "github.com/romapres2010/httpserver/log"
@@ -17,20 +18,57 @@ import (
 
 // Logger represent аn HTTP logger
 type Logger struct {
-	file     *os.File // файл логирования HTTP вызовов
-	cfg      *Config  // файл конфигурации
-	reqCount uint64   // уникальный номер запроса
+	writer   io.WriteCloser // ротируемый writer логирования HTTP вызовов
+	sink     Sink           // получатель структурных записей логирования
+	cfg      *Config        // файл конфигурации
+	reqCount uint64         // уникальный номер запроса
+	pending  sync.Map       // reqID -> requestMeta, для связи Log*Request с Log*Response (Policy.Decide, Stats, DurationMS)
+
+	entryCh      chan Entry     // буфер асинхронной записи; nil - писать синхронно
+	wg           sync.WaitGroup // ожидание завершения горутины асинхронной записи при Close
+	droppedCount uint64         // число записей, отброшенных из-за переполнения entryCh
+
+	closeMu sync.RWMutex // защищает closed от гонки с отправкой в entryCh при Close
+	closed  bool         // true после Close - writeEntry перестает писать в entryCh
+}
+
+// requestMeta - данные запроса, сохраняемые между Log*Request и Log*Response
+// для применения Policy и накопления Stats по соответствующему ответу
+type requestMeta struct {
+	method        string
+	path          string
+	start         time.Time
+	reqHeaderSize int64
+	reqBodySize   int64
 }
 
 // Config represent аn HTTP logger config
 type Config struct {
-	Enable     bool   // состояние логирования
-	LogInReq   bool   // логировать входящие запросы
-	LogOutReq  bool   // логировать исходящие запросы
-	LogInResp  bool   // логировать входящие ответы
-	LogOutResp bool   // логировать исходящие ответы
-	LogBody    bool   // логировать тело запроса
-	FileName   string // наименование файл логирования
+	Enable     bool     // состояние логирования
+	LogInReq   bool     // логировать входящие запросы
+	LogOutReq  bool     // логировать исходящие запросы
+	LogInResp  bool     // логировать входящие ответы
+	LogOutResp bool     // логировать исходящие ответы
+	LogBody    bool     // логировать тело запроса
+	FileName   string   // наименование файл логирования
+	SinkType   SinkType // тип получателя записей: "text" (по умолчанию), "json" или "zap"
+
+	MaxLogSizeMB int  // максимальный размер лог файла в мегабайтах до ротации, 0 - без ограничения
+	MaxBackups   int  // максимальное число хранимых архивных файлов, 0 - хранить все
+	MaxAgeDays   int  // максимальный возраст архивных файлов в днях, 0 - без ограничения
+	UseGzip      bool // сжимать архивные файлы gzip-ом
+	MaxBodyBytes int  // максимальный размер тела запроса/ответа для логирования, 0 - без ограничения
+
+	Policy *Policy // политика выборочного логирования и сэмплирования; nil - логировать все без сэмплирования
+
+	RedactHeaders     []string // имена заголовков, значения которых заменяются на "***" (например Authorization, Cookie)
+	RedactJSONFields  []string // имена полей JSON/form тела, значения которых заменяются на "***"
+	RedactQueryParams []string // имена query параметров URL, значения которых заменяются на "***"
+
+	Stats *Stats // коллектор статистики передачи данных; nil - статистика не собирается
+
+	AsyncBufferSize int            // размер буфера асинхронной записи логов, 0 - писать синхронно в горутине вызывающего
+	OnFull          OverflowPolicy // политика при переполнении буфера: "block" (по умолчанию), "drop", "drop_oldest"
 }
 
 // NewLogger - создает новый Logger
@@ -48,57 +86,146 @@ func NewLogger(ctx context.Context, cfg *Config) (*Logger, error) {
 			cfg.FileName = fmt.Sprintf(cfg.FileName, time.Now().Format("2006_01_02_150405"))
 		}
 
-		// Открываем файл для логирования
-		f, err := os.OpenFile(cfg.FileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-		if err != nil {
-			myerr := myerror.WithCause("6020", "Error open HTTP log file", "os.OpenFile", fmt.Sprintf("cfg.FileName='%s'", cfg.FileName), "", err.Error())
-			mylog.PrintfErrorStd(fmt.Sprintf("%+v", myerr))
-			return nil, err
-		}
+		// открываем ротируемый writer логирования
+		log.writer = newRotatingWriter(cfg)
+	}
+
+	// создаем получатель структурных записей в зависимости от cfg.SinkType
+	sink, err := newSink(cfg, log.writer)
+	if err != nil {
+		myerr := myerror.WithCause("6021", "Error create HTTP log sink", "newSink", fmt.Sprintf("cfg.SinkType='%s'", sinkTypeOf(cfg)), "", err.Error())
+		mylog.PrintfErrorStd(fmt.Sprintf("%+v", myerr))
+		return nil, err
+	}
+	log.sink = sink
 
-		// сохраняем открытый дескриптор файла логирования
-		log.file = f
+	// включаем асинхронную запись, если задан размер буфера
+	if cfg != nil && cfg.AsyncBufferSize > 0 {
+		log.startAsyncWriter(cfg.AsyncBufferSize)
 	}
 
 	return log, nil
 }
 
+// sinkTypeOf - возвращает тип получателя из конфига с учетом значения по умолчанию
+func sinkTypeOf(cfg *Config) SinkType {
+	if cfg == nil || cfg.SinkType == "" {
+		return SinkText
+	}
+	return cfg.SinkType
+}
+
+// newSink - создает Sink в соответствии с cfg.SinkType. Если writer == nil,
+// записи пишутся в ioutil.Discard, что сохраняет прежнее поведение -
+// логирование фактически выключено, если файл не задан
+func newSink(cfg *Config, writer io.Writer) (Sink, error) {
+	var w io.Writer = ioutil.Discard
+	if writer != nil {
+		w = writer
+	}
+
+	switch sinkTypeOf(cfg) {
+	case SinkJSON:
+		return newJSONSink(w), nil
+	case SinkZap:
+		return newZapSink(w)
+	default:
+		return newTextSink(w), nil
+	}
+}
+
 // GetNextReqID - запросить номер следующего запроса
 // =====================================================================
 func (log *Logger) GetNextReqID() uint64 {
 	return atomic.AddUint64(&log.reqCount, 1)
 }
 
-// Close - close Logger
-// =====================================================================
-func (log *Logger) Close() {
-	if log.file != nil {
-		_ = log.file.Close()
+// decide - применяет Policy логгера к методу/пути/статусу и возвращает
+// итоговую детализацию записи. log.cfg.Policy == nil означает "логировать все"
+func (log *Logger) decide(reqID uint64, method, urlPath string, status int) Verbosity {
+	return log.cfg.Policy.Decide(reqID, method, urlPath, status)
+}
+
+// recordStatsFromMeta - записывает в Stats итоговую RequestStat по уже
+// извлеченному requestMeta
+func (log *Logger) recordStatsFromMeta(reqID uint64, meta requestMeta, respHeaderSize, respBodySize int64) {
+	if log.cfg.Stats == nil {
+		return
 	}
+
+	bucket := meta.path
+	if bucket == "" {
+		bucket = meta.method
+	}
+
+	log.cfg.Stats.Record(RequestStat{
+		ReqID:              reqID,
+		Bucket:             bucket,
+		RequestHeaderSize:  meta.reqHeaderSize,
+		RequestBodySize:    nonNegative(meta.reqBodySize),
+		ResponseHeaderSize: respHeaderSize,
+		ResponseBodySize:   nonNegative(respBodySize),
+		Start:              meta.start,
+		Stop:               time.Now(),
+	})
+}
+
+// nonNegative - приводит отрицательный размер (например http.Request.ContentLength == -1,
+// когда длина неизвестна) к 0
+func nonNegative(n int64) int64 {
+	if n < 0 {
+		return 0
+	}
+	return n
 }
 
 // LogHTTPOutRequest process HTTP logging for Out request
 //================================================================
 func (log *Logger) LogHTTPOutRequest(ctx context.Context, req *http.Request) (uint64, error) {
-	var err error
-	var dump []byte
-
 	// запросим ID следующего Request
 	reqID := log.GetNextReqID()
 
 	// логируем
-	if log.cfg.Enable && log.file != nil {
+	if log.cfg.Enable {
 		// логируем запрос
 		if req != nil && log.cfg.LogOutReq {
-			dump, err = httputil.DumpRequestOut(req, log.cfg.LogBody)
-			if err != nil {
-				myerr := myerror.New("8020", fmt.Sprintf("Error dump HTTP Request"), "", "")
-				mylog.PrintfErrorStd(fmt.Sprintf("%+v", myerr)) // логируем сразу
-				return reqID, myerr
+			log.pending.Store(reqID, requestMeta{
+				method:        req.Method,
+				path:          req.URL.Path,
+				start:         time.Now(),
+				reqHeaderSize: headerSize(req.Header),
+				reqBodySize:   req.ContentLength,
+			})
+
+			verbosity := log.decide(reqID, req.Method, req.URL.Path, 0)
+			if verbosity == VerbositySkip {
+				return reqID, nil
+			}
+
+			entry := Entry{
+				Timestamp:  time.Now(),
+				Direction:  "out_request",
+				ReqID:      reqID,
+				TraceID:    traceIDFromContext(ctx),
+				SpanID:     spanIDFromContext(ctx),
+				Method:     req.Method,
+				URL:        redactQueryParams(req.URL.String(), log.cfg.RedactQueryParams),
+				ReqHeaders: redactHeaderValues(flattenHeader(req.Header), log.cfg.RedactHeaders),
+			}
+
+			if log.cfg.LogBody && verbosity == VerbosityFull {
+				body, err := requestBodyForLog(req, log.cfg)
+				if err != nil {
+					myerr := myerror.New("8020", fmt.Sprintf("Error read HTTP Request body"), "", "")
+					mylog.PrintfErrorStd(fmt.Sprintf("%+v", myerr)) // логируем сразу
+					return reqID, myerr
+				}
+				entry.ReqBody = truncateBody(body, log.cfg.MaxBodyBytes)
+			}
+
+			if err := log.writeEntry(entry); err != nil {
+				return reqID, err
 			}
-			fmt.Fprintf(log.file, "'%s' Out Request '%v' BEGIN ==================================================================== \n", mylog.GetTimestampStr(), reqID)
-			fmt.Fprintf(log.file, "%+v\n", string(dump))
-			fmt.Fprintf(log.file, "'%s' Out Request '%v' END ==================================================================== \n", mylog.GetTimestampStr(), reqID)
 		}
 	}
 
@@ -108,23 +235,63 @@ func (log *Logger) LogHTTPOutRequest(ctx context.Context, req *http.Request) (ui
 // LogHTTPOutResponse process HTTP logging for Out response
 //================================================================
 func (log *Logger) LogHTTPOutResponse(ctx context.Context, resp *http.Response, reqID uint64) error {
-	var err error
-	var dump []byte
+	// извлекаем метод/путь и время начала исходного запроса, сохраненные в LogHTTPOutRequest.
+	// Очистку делаем безусловно: исходящий запрос может завершиться транспортной
+	// ошибкой без resp, и это единственное место, где pending/Policy.sampled для
+	// данного reqID освобождаются - иначе они растут без ограничения.
+	// Forget отложен до выхода из функции, чтобы decide ниже получил вердикт
+	// сэмплирования, закэшированный в LogHTTPOutRequest
+	metaRaw, _ := log.pending.Load(reqID)
+	m, hasMeta := metaRaw.(requestMeta)
+	log.pending.Delete(reqID)
+	defer log.cfg.Policy.Forget(reqID)
 
 	// логируем
-	if log.cfg.Enable && log.file != nil {
+	if log.cfg.Enable {
 		// логируем запрос
 		if resp != nil && log.cfg.LogOutReq {
-			dump, err = httputil.DumpResponse(resp, log.cfg.LogBody)
-			if err != nil {
-				myerr := myerror.New("8020", fmt.Sprintf("Error dump HTTP Response"), "", "")
-				mylog.PrintfErrorStd(fmt.Sprintf("%+v", myerr)) // логируем сразу
-				return myerr
+			method, path := "", ""
+			if resp.Request != nil {
+				method = resp.Request.Method
+				path = resp.Request.URL.Path
 			}
 
-			fmt.Fprintf(log.file, "'%s' Out Response '%v' BEGIN ==================================================================== \n", mylog.GetTimestampStr(), reqID)
-			fmt.Fprintf(log.file, "%+v\n", string(dump))
-			fmt.Fprintf(log.file, "'%s' Out Response '%v' End ==================================================================== \n", mylog.GetTimestampStr(), reqID)
+			if hasMeta {
+				log.recordStatsFromMeta(reqID, m, headerSize(resp.Header), resp.ContentLength)
+			}
+
+			verbosity := log.decide(reqID, method, path, resp.StatusCode)
+			if verbosity == VerbositySkip {
+				return nil
+			}
+
+			entry := Entry{
+				Timestamp:   time.Now(),
+				Direction:   "out_response",
+				ReqID:       reqID,
+				TraceID:     traceIDFromContext(ctx),
+				SpanID:      spanIDFromContext(ctx),
+				Status:      resp.StatusCode,
+				RespHeaders: redactHeaderValues(flattenHeader(resp.Header), log.cfg.RedactHeaders),
+			}
+
+			if hasMeta {
+				entry.DurationMS = time.Since(m.start).Milliseconds()
+			}
+
+			if log.cfg.LogBody && verbosity == VerbosityFull {
+				body, err := responseBodyForLog(resp, log.cfg)
+				if err != nil {
+					myerr := myerror.New("8020", fmt.Sprintf("Error read HTTP Response body"), "", "")
+					mylog.PrintfErrorStd(fmt.Sprintf("%+v", myerr)) // логируем сразу
+					return myerr
+				}
+				entry.RespBody = truncateBody(body, log.cfg.MaxBodyBytes)
+			}
+
+			if err := log.writeEntry(entry); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -134,25 +301,51 @@ func (log *Logger) LogHTTPOutResponse(ctx context.Context, resp *http.Response,
 // LogHTTPInRequest process HTTP logging for In request
 //================================================================
 func (log *Logger) LogHTTPInRequest(ctx context.Context, req *http.Request) (uint64, error) {
-	var err error
-	var dump []byte
-
 	// запросим ID следующего Request
 	reqID := log.GetNextReqID()
 
-	// логируем в файл
-	if log.cfg.Enable && log.file != nil {
+	// логируем
+	if log.cfg.Enable {
 		// логируем запрос
 		if req != nil && log.cfg.LogInReq {
-			dump, err = httputil.DumpRequest(req, log.cfg.LogBody)
-			if err != nil {
-				myerr := myerror.New("8020", fmt.Sprintf("Error dump HTTP Request"), "", "")
-				mylog.PrintfErrorStd(fmt.Sprintf("%+v", myerr)) // логируем сразу
-				return reqID, myerr
+			log.pending.Store(reqID, requestMeta{
+				method:        req.Method,
+				path:          req.URL.Path,
+				start:         time.Now(),
+				reqHeaderSize: headerSize(req.Header),
+				reqBodySize:   req.ContentLength,
+			})
+
+			verbosity := log.decide(reqID, req.Method, req.URL.Path, 0)
+			if verbosity == VerbositySkip {
+				return reqID, nil
+			}
+
+			entry := Entry{
+				Timestamp:  time.Now(),
+				Direction:  "in_request",
+				ReqID:      reqID,
+				TraceID:    traceIDFromContext(ctx),
+				SpanID:     spanIDFromContext(ctx),
+				Method:     req.Method,
+				URL:        redactQueryParams(req.URL.String(), log.cfg.RedactQueryParams),
+				RemoteAddr: req.RemoteAddr,
+				ReqHeaders: redactHeaderValues(flattenHeader(req.Header), log.cfg.RedactHeaders),
+			}
+
+			if log.cfg.LogBody && verbosity == VerbosityFull {
+				body, err := requestBodyForLog(req, log.cfg)
+				if err != nil {
+					myerr := myerror.New("8020", fmt.Sprintf("Error read HTTP Request body"), "", "")
+					mylog.PrintfErrorStd(fmt.Sprintf("%+v", myerr)) // логируем сразу
+					return reqID, myerr
+				}
+				entry.ReqBody = truncateBody(body, log.cfg.MaxBodyBytes)
+			}
+
+			if err := log.writeEntry(entry); err != nil {
+				return reqID, err
 			}
-			fmt.Fprintf(log.file, "'%s' In Request '%v' BEGIN ==================================================================== \n", mylog.GetTimestampStr(), reqID)
-			fmt.Fprintf(log.file, "%+v\n", string(dump))
-			fmt.Fprintf(log.file, "'%s' In Request '%v' End ==================================================================== \n", mylog.GetTimestampStr(), reqID)
 		}
 	}
 
@@ -162,31 +355,64 @@ func (log *Logger) LogHTTPInRequest(ctx context.Context, req *http.Request) (uin
 // LogHTTPInResponse process HTTP logging for In Response
 //================================================================
 func (log *Logger) LogHTTPInResponse(ctx context.Context, header map[string]string, responseBuf []byte, status int, reqID uint64) error {
-	// логируем в файл
-	if log.cfg.Enable && log.file != nil && log.cfg.LogInResp {
-		// сформируем буффер с ответом
-		dump := make([]byte, 0)
-
-		// добавим статус ответа
-		dump = append(dump, []byte(fmt.Sprintf("HTTP %v %s\n", status, http.StatusText(status)))...)
-
-		// соберем все заголовки в буфер для логирования
-		if header != nil {
-			for k, v := range header {
-				dump = append(dump, []byte(fmt.Sprintf("%s: %s\n", k, v))...)
-			}
+	// извлекаем метод/путь исходного запроса, сохраненные в LogHTTPInRequest
+	meta, _ := log.pending.Load(reqID)
+	log.pending.Delete(reqID)
+
+	method, path := "", ""
+	m, hasMeta := meta.(requestMeta)
+	if hasMeta {
+		method, path = m.method, m.path
+	}
+
+	// логируем
+	if log.cfg.Enable && log.cfg.LogInResp {
+		if hasMeta {
+			log.recordStatsFromMeta(reqID, m, headerSizeFlat(header), int64(len(responseBuf)))
+		}
+
+		verbosity := log.decide(reqID, method, path, status)
+		if verbosity == VerbositySkip {
+			return nil
+		}
+
+		entry := Entry{
+			Timestamp:   time.Now(),
+			Direction:   "in_response",
+			ReqID:       reqID,
+			TraceID:     traceIDFromContext(ctx),
+			SpanID:      spanIDFromContext(ctx),
+			Status:      status,
+			RespHeaders: redactHeaderValues(header, log.cfg.RedactHeaders),
 		}
 
-		// Логируем тело
-		if log.cfg.LogBody && responseBuf != nil {
-			dump = append(dump, []byte("\n")...)
-			dump = append(dump, responseBuf...)
+		if hasMeta {
+			entry.DurationMS = time.Since(m.start).Milliseconds()
 		}
 
-		fmt.Fprintf(log.file, "'%s' In Response '%v' BEGIN ==================================================================== \n", mylog.GetTimestampStr(), reqID)
-		fmt.Fprintf(log.file, "%+v\n", string(dump))
-		fmt.Fprintf(log.file, "'%s' In Response '%v' End ==================================================================== \n", mylog.GetTimestampStr(), reqID)
+		if log.cfg.LogBody && responseBuf != nil && verbosity == VerbosityFull {
+			body := formatBody(header["Content-Type"], responseBuf, log.cfg)
+			entry.RespBody = truncateBody(body, log.cfg.MaxBodyBytes)
+		}
+
+		if err := log.writeEntry(entry); err != nil {
+			return err
+		}
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// flattenHeader - преобразует http.Header (map[string][]string) в map[string]string,
+// объединяя множественные значения через запятую
+func flattenHeader(header http.Header) map[string]string {
+	if len(header) == 0 {
+		return nil
+	}
+
+	flat := make(map[string]string, len(header))
+	for k, v := range header {
+		flat[k] = strings.Join(v, ", ")
+	}
+	return flat
+}
@@ -0,0 +1,108 @@
+package httplog
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// responseCapture - оборачивает http.ResponseWriter, буферизуя тело ответа
+// (до maxBodyBytes) и запоминая присвоенный статус, чтобы их можно было
+// передать в LogHTTPInResponse после завершения обработки запроса
+type responseCapture struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	maxBody     int
+	wroteHeader bool
+}
+
+// newResponseCapture - создает новый responseCapture поверх w
+func newResponseCapture(w http.ResponseWriter, maxBody int) *responseCapture {
+	return &responseCapture{ResponseWriter: w, maxBody: maxBody}
+}
+
+// WriteHeader - запоминает статус ответа и пробрасывает вызов дальше
+func (rc *responseCapture) WriteHeader(status int) {
+	rc.status = status
+	rc.wroteHeader = true
+	rc.ResponseWriter.WriteHeader(status)
+}
+
+// Write - буферизует тело ответа (с учетом ограничения maxBody) и пробрасывает
+// запись в оригинальный http.ResponseWriter
+func (rc *responseCapture) Write(b []byte) (int, error) {
+	if !rc.wroteHeader {
+		rc.WriteHeader(http.StatusOK)
+	}
+
+	if rc.maxBody <= 0 || rc.body.Len() < rc.maxBody {
+		remain := rc.maxBody - rc.body.Len()
+		if rc.maxBody <= 0 || remain > len(b) {
+			rc.body.Write(b)
+		} else {
+			rc.body.Write(b[:remain])
+		}
+	}
+
+	return rc.ResponseWriter.Write(b)
+}
+
+// Hijack - пробрасывает http.Hijacker, если исходный ResponseWriter его
+// поддерживает. Без этого WebSocket-апгрейды за Middleware ломаются, так как
+// responseCapture встраивает http.ResponseWriter только через интерфейс
+func (rc *responseCapture) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := rc.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return hj.Hijack()
+}
+
+// Flush - пробрасывает http.Flusher, если исходный ResponseWriter его
+// поддерживает. Нужно для потоковых ответов (SSE, chunked) за Middleware
+func (rc *responseCapture) Flush() {
+	if f, ok := rc.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// CloseNotify - пробрасывает устаревший http.CloseNotifier, если исходный
+// ResponseWriter его поддерживает
+func (rc *responseCapture) CloseNotify() <-chan bool {
+	if cn, ok := rc.ResponseWriter.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return nil
+}
+
+// Middleware - оборачивает http.Handler логированием входящего запроса/ответа:
+// присваивает reqID, кладет его в context и в заголовок ответа X-Request-ID
+// (с учетом уже переданного клиентом значения), буферизует тело ответа через
+// responseCapture и вызывает LogHTTPInRequest/LogHTTPInResponse вокруг
+// обработчика
+// =====================================================================
+func (log *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqID, _ := log.LogHTTPInRequest(r.Context(), r)
+
+		// берем reqID из входящего заголовка, если он уже задан вызывающей стороной
+		headerReqID := r.Header.Get(HeaderReqID)
+		if headerReqID == "" {
+			headerReqID = strconv.FormatUint(reqID, 10)
+		}
+
+		ctx := WithReqID(r.Context(), reqID)
+		r = r.WithContext(ctx)
+
+		w.Header().Set(HeaderReqID, headerReqID)
+
+		rc := newResponseCapture(w, log.cfg.MaxBodyBytes)
+
+		next.ServeHTTP(rc, r)
+
+		_ = log.LogHTTPInResponse(ctx, flattenHeader(rc.Header()), rc.body.Bytes(), rc.status, reqID)
+	})
+}
@@ -0,0 +1,140 @@
+package httplog
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseCaptureWriteCapturesStatusAndBody(t *testing.T) {
+	w := httptest.NewRecorder()
+	rc := newResponseCapture(w, 0)
+
+	rc.WriteHeader(http.StatusCreated)
+	if _, err := rc.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if rc.status != http.StatusCreated {
+		t.Errorf("status = %d, want %d", rc.status, http.StatusCreated)
+	}
+	if rc.body.String() != "hello" {
+		t.Errorf("captured body = %q, want %q", rc.body.String(), "hello")
+	}
+	if w.Body.String() != "hello" {
+		t.Errorf("underlying writer body = %q, want %q", w.Body.String(), "hello")
+	}
+}
+
+func TestResponseCaptureWriteDefaultsStatusOK(t *testing.T) {
+	w := httptest.NewRecorder()
+	rc := newResponseCapture(w, 0)
+
+	if _, err := rc.Write([]byte("hi")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if rc.status != http.StatusOK {
+		t.Errorf("status = %d, want %d", rc.status, http.StatusOK)
+	}
+}
+
+func TestResponseCaptureWriteRespectsMaxBody(t *testing.T) {
+	w := httptest.NewRecorder()
+	rc := newResponseCapture(w, 3)
+
+	if _, err := rc.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if rc.body.String() != "hel" {
+		t.Errorf("captured body = %q, want %q (truncated to maxBody)", rc.body.String(), "hel")
+	}
+	if w.Body.String() != "hello" {
+		t.Errorf("underlying writer body = %q, want %q (full body still forwarded)", w.Body.String(), "hello")
+	}
+}
+
+// hijackableRecorder - httptest.ResponseRecorder не реализует http.Hijacker/http.Flusher,
+// поэтому для проверки passthrough нужен собственный writer
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+	flushed  bool
+}
+
+func (h *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+
+func (h *hijackableRecorder) Flush() {
+	h.flushed = true
+}
+
+func TestResponseCaptureHijackNotSupported(t *testing.T) {
+	w := httptest.NewRecorder()
+	rc := newResponseCapture(w, 0)
+
+	if _, _, err := rc.Hijack(); err != http.ErrNotSupported {
+		t.Errorf("Hijack() error = %v, want %v", err, http.ErrNotSupported)
+	}
+}
+
+func TestResponseCaptureHijackDelegates(t *testing.T) {
+	underlying := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder()}
+	rc := newResponseCapture(underlying, 0)
+
+	if _, _, err := rc.Hijack(); err != nil {
+		t.Fatalf("Hijack() error = %v", err)
+	}
+	if !underlying.hijacked {
+		t.Error("Hijack() did not delegate to underlying ResponseWriter")
+	}
+}
+
+func TestResponseCaptureFlushDelegates(t *testing.T) {
+	underlying := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder()}
+	rc := newResponseCapture(underlying, 0)
+
+	rc.Flush()
+	if !underlying.flushed {
+		t.Error("Flush() did not delegate to underlying ResponseWriter")
+	}
+}
+
+func TestResponseCaptureFlushNoopWhenUnsupported(t *testing.T) {
+	w := httptest.NewRecorder()
+	rc := newResponseCapture(w, 0)
+
+	rc.Flush() // не должно паниковать
+}
+
+func TestMiddlewareSetsRequestIDHeaderAndForwardsResponse(t *testing.T) {
+	log := &Logger{cfg: &Config{}}
+
+	handler := log.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := ReqIDFromContext(r.Context()); !ok {
+			t.Error("handler context is missing reqID set by Middleware")
+		}
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("body"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Header().Get(HeaderReqID) == "" {
+		t.Error("Middleware did not set X-Request-ID response header")
+	}
+	if w.Code != http.StatusTeapot {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusTeapot)
+	}
+	if w.Body.String() != "body" {
+		t.Errorf("body = %q, want %q", w.Body.String(), "body")
+	}
+}
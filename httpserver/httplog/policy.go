@@ -0,0 +1,176 @@
+package httplog
+
+import (
+	"path"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Verbosity - уровень детализации записи лога, выбираемый Policy
+type Verbosity int
+
+// Допустимые значения Verbosity
+const (
+	VerbositySkip         Verbosity = iota // не логировать событие вообще
+	VerbosityHeadersOnly                   // логировать только заголовки, без тела
+	VerbosityFull                          // логировать заголовки и тело
+)
+
+// Rule - одно правило политики логирования. Правило применяется, если
+// запрос подходит под Methods, PathPattern и (если задан) StatusClass.
+// Подходящие под правило запросы сэмплируются согласно SampleRate
+type Rule struct {
+	Methods     []string  // HTTP методы, к которым применяется правило; пусто - любой метод
+	PathPattern string    // шаблон пути в формате path.Match, например "/health" или "/api/*"
+	StatusClass string    // "2xx", "3xx", "4xx", "5xx"; пусто - не зависит от статуса
+	Verbosity   Verbosity // уровень детализации при попадании в правило
+	SampleRate  float64   // доля запросов для логирования, (0;1]; 0 или 1 - логировать все подходящие
+}
+
+// Policy - политика логирования: выбирает, логировать ли конкретное HTTP
+// событие и с какой детализацией, на основании метода, пути, класса статуса
+// ответа и сэмплирования
+type Policy struct {
+	rules    []Rule
+	fallback Verbosity
+	counters []uint64 // счетчики сэмплирования, по одному на правило
+	sampled  sync.Map // reqID -> sampleVerdict, переиспользуется между request- и response-фазой одного logical exchange
+}
+
+// sampleVerdict - результат сэмплирования, закэшированный по reqID, чтобы
+// request-фаза (Decide со status==0) и response-фаза (Decide с реальным
+// status) одного и того же запроса получали одинаковый вердикт, а не тянули
+// счетчик правила дважды
+type sampleVerdict struct {
+	rule int  // индекс правила, для которого закэширован вердикт
+	hit  bool // прошел ли запрос сэмплирование по этому правилу
+}
+
+// NewPolicy - создает новую Policy. rules проверяются по порядку, срабатывает
+// первое подходящее правило; fallback применяется, если ни одно правило не подошло
+func NewPolicy(rules []Rule, fallback Verbosity) *Policy {
+	return &Policy{
+		rules:    rules,
+		fallback: fallback,
+		counters: make([]uint64, len(rules)),
+	}
+}
+
+// Decide - возвращает детализацию логирования для запроса method+urlPath.
+// status передается равным 0, если статус ответа еще не известен (на этапе
+// логирования запроса) - правила с заданным StatusClass в этом случае
+// пропускаются. reqID используется, чтобы закэшировать вердикт сэмплирования
+// и переиспользовать его в парном вызове Decide для того же запроса
+// (request-фаза с status==0 и response-фаза с реальным status) - иначе
+// обе фазы тянут общий счетчик правила независимо и могут разойтись
+// (запрос залогирован, а ответ - нет, или наоборот)
+func (p *Policy) Decide(reqID uint64, method, urlPath string, status int) Verbosity {
+	if p == nil {
+		return VerbosityFull
+	}
+
+	// response-фаза - последний вызов Decide для этого reqID, после нее
+	// закэшированный вердикт больше не нужен
+	isResponsePhase := status != 0
+	if isResponsePhase {
+		defer p.sampled.Delete(reqID)
+	}
+
+	for i := range p.rules {
+		rule := &p.rules[i]
+
+		if !rule.matchesMethod(method) {
+			continue
+		}
+		if !rule.matchesPath(urlPath) {
+			continue
+		}
+		if rule.StatusClass != "" {
+			if status == 0 || !rule.matchesStatusClass(status) {
+				continue
+			}
+		}
+
+		if rule.SampleRate > 0 && rule.SampleRate < 1 {
+			if !p.sampleRuleHit(reqID, i, rule.SampleRate) {
+				return VerbositySkip
+			}
+		}
+
+		return rule.Verbosity
+	}
+
+	return p.fallback
+}
+
+// Forget - удаляет закэшированный вердикт сэмплирования для reqID. Нужно
+// вызывать явно, когда парного вызова Decide в response-фазе не будет
+// (например, исходящий запрос завершился транспортной ошибкой без ответа) -
+// иначе запись в sampled остается до конца жизни процесса
+func (p *Policy) Forget(reqID uint64) {
+	if p == nil {
+		return
+	}
+	p.sampled.Delete(reqID)
+}
+
+// sampleRuleHit - возвращает вердикт сэмплирования для правила i. Если для
+// reqID уже закэширован вердикт этого же правила (из парного вызова Decide),
+// переиспользует его вместо повторного обращения к счетчику
+func (p *Policy) sampleRuleHit(reqID uint64, rule int, rate float64) bool {
+	if v, ok := p.sampled.Load(reqID); ok {
+		if sv := v.(sampleVerdict); sv.rule == rule {
+			return sv.hit
+		}
+	}
+
+	n := atomic.AddUint64(&p.counters[rule], 1)
+	hit := sampleHit(n, rate)
+	p.sampled.Store(reqID, sampleVerdict{rule: rule, hit: hit})
+	return hit
+}
+
+// matchesMethod - проверяет метод запроса против Rule.Methods
+func (r *Rule) matchesMethod(method string) bool {
+	if len(r.Methods) == 0 {
+		return true
+	}
+	for _, m := range r.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPath - проверяет путь запроса против Rule.PathPattern
+func (r *Rule) matchesPath(urlPath string) bool {
+	if r.PathPattern == "" {
+		return true
+	}
+	ok, err := path.Match(r.PathPattern, urlPath)
+	return err == nil && ok
+}
+
+// matchesStatusClass - проверяет статус ответа против Rule.StatusClass ("2xx" и т.п.)
+func (r *Rule) matchesStatusClass(status int) bool {
+	if len(r.StatusClass) != 3 || r.StatusClass[1:] != "xx" {
+		return false
+	}
+	class, err := strconv.Atoi(r.StatusClass[:1])
+	if err != nil {
+		return false
+	}
+	return status/100 == class
+}
+
+// sampleHit - детерминированное сэмплирование 1/N: логируется каждый N-й подходящий
+// запрос, где N = round(1/rate)
+func sampleHit(n uint64, rate float64) bool {
+	every := uint64(1.0/rate + 0.5)
+	if every <= 1 {
+		return true
+	}
+	return n%every == 0
+}
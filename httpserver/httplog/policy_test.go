@@ -0,0 +1,162 @@
+package httplog
+
+import "testing"
+
+func TestSampleHit(t *testing.T) {
+	tests := []struct {
+		name string
+		n    uint64
+		rate float64
+		want bool
+	}{
+		{"rate 1 always hits", 3, 1, true},
+		{"every 2nd, miss on 1", 1, 0.5, false},
+		{"every 2nd, hit on 2", 2, 0.5, true},
+		{"every 4th, miss on 3", 3, 0.25, false},
+		{"every 4th, hit on 4", 4, 0.25, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sampleHit(tt.n, tt.rate); got != tt.want {
+				t.Errorf("sampleHit(%d, %v) = %v, want %v", tt.n, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesStatusClass(t *testing.T) {
+	tests := []struct {
+		name   string
+		class  string
+		status int
+		want   bool
+	}{
+		{"2xx matches 200", "2xx", 200, true},
+		{"2xx matches 204", "2xx", 204, true},
+		{"4xx does not match 200", "4xx", 200, false},
+		{"5xx matches 503", "5xx", 503, true},
+		{"invalid class", "abc", 200, false},
+		{"digit instead of xx suffix", "20x", 200, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Rule{StatusClass: tt.class}
+			if got := r.matchesStatusClass(tt.status); got != tt.want {
+				t.Errorf("matchesStatusClass(%d) with class %q = %v, want %v", tt.status, tt.class, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesMethod(t *testing.T) {
+	tests := []struct {
+		name    string
+		methods []string
+		method  string
+		want    bool
+	}{
+		{"empty matches any", nil, "GET", true},
+		{"method in list matches", []string{"GET", "POST"}, "POST", true},
+		{"method not in list does not match", []string{"GET", "POST"}, "DELETE", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Rule{Methods: tt.methods}
+			if got := r.matchesMethod(tt.method); got != tt.want {
+				t.Errorf("matchesMethod(%q) with methods %v = %v, want %v", tt.method, tt.methods, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"empty pattern matches any", "", "/anything", true},
+		{"exact match", "/health", "/health", true},
+		{"exact mismatch", "/health", "/healthz", false},
+		{"glob match", "/api/*", "/api/users", true},
+		{"glob mismatch", "/api/*", "/other/users", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Rule{PathPattern: tt.pattern}
+			if got := r.matchesPath(tt.path); got != tt.want {
+				t.Errorf("matchesPath(%q) with pattern %q = %v, want %v", tt.path, tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDecideSamplesOncePerRequest проверяет, что request-фаза (status==0) и
+// response-фаза (status!=0) одного и того же reqID получают одинаковый
+// вердикт сэмплирования, а не тянут общий счетчик правила независимо
+func TestDecideSamplesOncePerRequest(t *testing.T) {
+	p := NewPolicy([]Rule{
+		{PathPattern: "/api/*", Verbosity: VerbosityFull, SampleRate: 0.5},
+	}, VerbositySkip)
+
+	for reqID := uint64(1); reqID <= 20; reqID++ {
+		reqVerdict := p.Decide(reqID, "GET", "/api/users", 0)
+		respVerdict := p.Decide(reqID, "GET", "/api/users", 200)
+
+		if reqVerdict != respVerdict {
+			t.Fatalf("reqID %d: request phase verdict %v != response phase verdict %v", reqID, reqVerdict, respVerdict)
+		}
+	}
+}
+
+// TestDecideForgetsSampleAfterResponsePhase проверяет, что закэшированный
+// вердикт не остается в памяти после response-фазы и не просачивается в
+// вердикт другого запроса
+func TestDecideForgetsSampleAfterResponsePhase(t *testing.T) {
+	p := NewPolicy([]Rule{
+		{PathPattern: "/api/*", Verbosity: VerbosityFull, SampleRate: 0.5},
+	}, VerbositySkip)
+
+	p.Decide(1, "GET", "/api/users", 0)
+	p.Decide(1, "GET", "/api/users", 200)
+
+	if _, ok := p.sampled.Load(uint64(1)); ok {
+		t.Fatalf("sample verdict for reqID 1 should be forgotten after response phase")
+	}
+}
+
+func TestDecideFirstMatchingRuleWins(t *testing.T) {
+	p := NewPolicy([]Rule{
+		{PathPattern: "/health", Verbosity: VerbositySkip},
+		{PathPattern: "/*", Verbosity: VerbosityFull},
+	}, VerbosityHeadersOnly)
+
+	if got := p.Decide(1, "GET", "/health", 0); got != VerbositySkip {
+		t.Errorf("Decide(/health) = %v, want %v", got, VerbositySkip)
+	}
+	if got := p.Decide(2, "GET", "/other", 0); got != VerbosityFull {
+		t.Errorf("Decide(/other) = %v, want %v", got, VerbosityFull)
+	}
+}
+
+func TestDecideFallback(t *testing.T) {
+	p := NewPolicy([]Rule{
+		{PathPattern: "/health", Verbosity: VerbositySkip},
+	}, VerbosityHeadersOnly)
+
+	if got := p.Decide(1, "GET", "/other", 0); got != VerbosityHeadersOnly {
+		t.Errorf("Decide(/other) = %v, want fallback %v", got, VerbosityHeadersOnly)
+	}
+}
+
+func TestDecideNilPolicy(t *testing.T) {
+	var p *Policy
+	if got := p.Decide(1, "GET", "/anything", 0); got != VerbosityFull {
+		t.Errorf("Decide on nil Policy = %v, want %v", got, VerbosityFull)
+	}
+}
@@ -0,0 +1,62 @@
+package httplog
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PromCollector - опциональный prometheus.Collector, экспортирующий
+// агрегированную статистику Stats по бакетам
+type PromCollector struct {
+	stats *Stats
+
+	count      *prometheus.Desc
+	durationMS *prometheus.Desc
+	bytes      *prometheus.Desc
+}
+
+// NewPromCollector - создает новый PromCollector поверх stats
+func NewPromCollector(stats *Stats) *PromCollector {
+	return &PromCollector{
+		stats: stats,
+		count: prometheus.NewDesc(
+			"httplog_requests_total",
+			"Количество обработанных запросов в бакете",
+			[]string{"bucket"}, nil,
+		),
+		durationMS: prometheus.NewDesc(
+			"httplog_request_duration_ms",
+			"Длительность обработки запроса в бакете, миллисекунды",
+			[]string{"bucket", "quantile"}, nil,
+		),
+		bytes: prometheus.NewDesc(
+			"httplog_transfer_bytes_total",
+			"Суммарный объем переданных данных в бакете",
+			[]string{"bucket", "direction", "part"}, nil,
+		),
+	}
+}
+
+// Describe - реализация prometheus.Collector
+func (c *PromCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.count
+	ch <- c.durationMS
+	ch <- c.bytes
+}
+
+// Collect - реализация prometheus.Collector: на каждый сбор метрик строит
+// снимок текущей статистики Stats
+func (c *PromCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.stats.snapshot() {
+		ch <- prometheus.MustNewConstMetric(c.count, prometheus.CounterValue, float64(s.Count), s.Bucket)
+
+		ch <- prometheus.MustNewConstMetric(c.durationMS, prometheus.GaugeValue, float64(s.MinMS), s.Bucket, "min")
+		ch <- prometheus.MustNewConstMetric(c.durationMS, prometheus.GaugeValue, float64(s.MaxMS), s.Bucket, "max")
+		ch <- prometheus.MustNewConstMetric(c.durationMS, prometheus.GaugeValue, s.AvgMS, s.Bucket, "avg")
+		ch <- prometheus.MustNewConstMetric(c.durationMS, prometheus.GaugeValue, float64(s.P95MS), s.Bucket, "p95")
+
+		ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.CounterValue, float64(s.ReqHeaderBytes), s.Bucket, "request", "header")
+		ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.CounterValue, float64(s.ReqBodyBytes), s.Bucket, "request", "body")
+		ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.CounterValue, float64(s.RespHeaderBytes), s.Bucket, "response", "header")
+		ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.CounterValue, float64(s.RespBodyBytes), s.Bucket, "response", "body")
+	}
+}
@@ -0,0 +1,66 @@
+package httplog
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestPromCollectorDescribe(t *testing.T) {
+	c := NewPromCollector(NewStats())
+
+	ch := make(chan *prometheus.Desc, 10)
+	c.Describe(ch)
+	close(ch)
+
+	var descs []*prometheus.Desc
+	for d := range ch {
+		descs = append(descs, d)
+	}
+	if len(descs) != 3 {
+		t.Errorf("Describe() sent %d descs, want 3", len(descs))
+	}
+}
+
+func TestPromCollectorCollectEmitsRequestCount(t *testing.T) {
+	stats := NewStats()
+	stats.Record(RequestStat{Bucket: "api", DurationMS: 10, RequestBodySize: 100})
+
+	c := NewPromCollector(stats)
+
+	ch := make(chan prometheus.Metric, 64)
+	c.Collect(ch)
+	close(ch)
+
+	var found bool
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if pb.Counter != nil && pb.Counter.GetValue() == 1 {
+			for _, l := range pb.Label {
+				if l.GetName() == "bucket" && l.GetValue() == "api" {
+					found = true
+				}
+			}
+		}
+	}
+
+	if !found {
+		t.Error("Collect() did not emit a requests_total counter of 1 for bucket \"api\"")
+	}
+}
+
+func TestPromCollectorCollectEmptyStats(t *testing.T) {
+	c := NewPromCollector(NewStats())
+
+	ch := make(chan prometheus.Metric, 8)
+	c.Collect(ch)
+	close(ch)
+
+	for range ch {
+		t.Error("Collect() on empty Stats should emit no metrics")
+	}
+}
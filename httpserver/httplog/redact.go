@@ -0,0 +1,204 @@
+package httplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const redactedMask = "***"
+
+// redactHeaderValues - заменяет значения заголовков из names на "***".
+// Сравнение имен регистронезависимое, как того требует HTTP
+func redactHeaderValues(headers map[string]string, names []string) map[string]string {
+	if len(headers) == 0 || len(names) == 0 {
+		return headers
+	}
+
+	for _, name := range names {
+		for k := range headers {
+			if strings.EqualFold(k, name) {
+				headers[k] = redactedMask
+			}
+		}
+	}
+	return headers
+}
+
+// redactQueryParams - заменяет значения query параметров из names на "***"
+// в строке URL
+func redactQueryParams(rawURL string, names []string) string {
+	if len(names) == 0 {
+		return rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	q := u.Query()
+	changed := false
+	for _, name := range names {
+		if _, ok := q[name]; ok {
+			q.Set(name, redactedMask)
+			changed = true
+		}
+	}
+	if !changed {
+		return rawURL
+	}
+
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// mediaTypeOf - извлекает media type из заголовка Content-Type без параметров;
+// при ошибке разбора берет то, что до первой ";"
+func mediaTypeOf(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	}
+	return strings.ToLower(mediaType)
+}
+
+// isBinaryMediaType - true для типов, которые formatBody не разбирает, а
+// только суммирует как "Content-Type: X, Size: Y" - для таких тел нет смысла
+// читать их в память целиком (см. bodyutil.go requestBodyForLog/responseBodyForLog)
+func isBinaryMediaType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "image/") || mediaType == "application/octet-stream"
+}
+
+// formatBody - приводит тело запроса/ответа к виду, пригодному для записи в
+// лог: для JSON и form - с редактированием полей из RedactJSONFields и
+// красивой печатью, для XML - только красивая печать без редактирования полей,
+// для бинарных - только Content-Type и размер
+func formatBody(contentType string, body []byte, cfg *Config) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	mediaType := mediaTypeOf(contentType)
+
+	switch {
+	case isBinaryMediaType(mediaType):
+		return []byte(fmt.Sprintf("Content-Type: %s, Size: %d bytes", contentType, len(body)))
+	case mediaType == "application/json":
+		return prettyJSON(body, cfg.RedactJSONFields)
+	case mediaType == "application/xml", strings.HasSuffix(mediaType, "+xml"):
+		return prettyXML(body)
+	case mediaType == "application/x-www-form-urlencoded":
+		return prettyForm(body, cfg.RedactJSONFields)
+	default:
+		return body
+	}
+}
+
+// prettyJSON - парсит JSON, заменяет поля из redactFields на "***" и
+// выводит результат с отступами. При ошибке разбора возвращает тело как есть
+func prettyJSON(body []byte, redactFields []string) []byte {
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return body
+	}
+
+	if len(redactFields) > 0 {
+		fields := make(map[string]bool, len(redactFields))
+		for _, f := range redactFields {
+			fields[f] = true
+		}
+		data = redactJSONValue(data, fields)
+	}
+
+	pretty, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return body
+	}
+	return pretty
+}
+
+// redactJSONValue - рекурсивно обходит разобранный JSON и заменяет значения
+// полей из fields на "***"
+func redactJSONValue(v interface{}, fields map[string]bool) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k, val := range vv {
+			if fields[k] {
+				vv[k] = redactedMask
+				continue
+			}
+			vv[k] = redactJSONValue(val, fields)
+		}
+		return vv
+	case []interface{}:
+		for i, val := range vv {
+			vv[i] = redactJSONValue(val, fields)
+		}
+		return vv
+	default:
+		return v
+	}
+}
+
+// prettyXML - переформатирует XML с отступами без знания конкретной схемы
+func prettyXML(body []byte) []byte {
+	var out bytes.Buffer
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	enc := xml.NewEncoder(&out)
+	enc.Indent("", "  ")
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return body
+		}
+		if err := enc.EncodeToken(tok); err != nil {
+			return body
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return body
+	}
+	return out.Bytes()
+}
+
+// prettyForm - разбирает application/x-www-form-urlencoded тело, заменяет
+// значения полей из redactFields на "***" и печатает по одной паре на строку
+func prettyForm(body []byte, redactFields []string) []byte {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return body
+	}
+
+	fields := make(map[string]bool, len(redactFields))
+	for _, f := range redactFields {
+		fields[f] = true
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var out bytes.Buffer
+	for _, k := range keys {
+		for _, v := range values[k] {
+			if fields[k] {
+				v = redactedMask
+			}
+			fmt.Fprintf(&out, "%s: %s\n", k, v)
+		}
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,156 @@
+package httplog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactHeaderValues(t *testing.T) {
+	headers := map[string]string{
+		"Authorization": "Bearer xyz",
+		"Content-Type":  "application/json",
+	}
+
+	got := redactHeaderValues(headers, []string{"authorization"})
+
+	if got["Authorization"] != redactedMask {
+		t.Errorf("Authorization = %q, want %q", got["Authorization"], redactedMask)
+	}
+	if got["Content-Type"] != "application/json" {
+		t.Errorf("Content-Type = %q, want unchanged", got["Content-Type"])
+	}
+}
+
+func TestRedactHeaderValuesNoNames(t *testing.T) {
+	headers := map[string]string{"Authorization": "Bearer xyz"}
+	got := redactHeaderValues(headers, nil)
+	if got["Authorization"] != "Bearer xyz" {
+		t.Errorf("headers modified with no names given: %v", got)
+	}
+}
+
+func TestRedactQueryParams(t *testing.T) {
+	tests := []struct {
+		name  string
+		url   string
+		names []string
+		want  string
+	}{
+		{"no names unchanged", "/api?token=secret", nil, "/api?token=secret"},
+		{"redacts matching param", "/api?token=secret&id=1", []string{"token"}, "/api?id=1&token=%2A%2A%2A"},
+		{"param absent leaves url unchanged", "/api?id=1", []string{"token"}, "/api?id=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactQueryParams(tt.url, tt.names); got != tt.want {
+				t.Errorf("redactQueryParams(%q, %v) = %q, want %q", tt.url, tt.names, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediaTypeOf(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"application/json", "application/json"},
+		{"application/json; charset=utf-8", "application/json"},
+		{"Image/PNG", "image/png"},
+		{"not a valid;;; media type", "not a valid"},
+	}
+
+	for _, tt := range tests {
+		if got := mediaTypeOf(tt.contentType); got != tt.want {
+			t.Errorf("mediaTypeOf(%q) = %q, want %q", tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestIsBinaryMediaType(t *testing.T) {
+	tests := []struct {
+		mediaType string
+		want      bool
+	}{
+		{"image/png", true},
+		{"image/jpeg", true},
+		{"application/octet-stream", true},
+		{"application/json", false},
+		{"text/plain", false},
+	}
+
+	for _, tt := range tests {
+		if got := isBinaryMediaType(tt.mediaType); got != tt.want {
+			t.Errorf("isBinaryMediaType(%q) = %v, want %v", tt.mediaType, got, tt.want)
+		}
+	}
+}
+
+func TestFormatBodyJSONRedactsFields(t *testing.T) {
+	body := []byte(`{"user":"alice","password":"hunter2"}`)
+	cfg := &Config{RedactJSONFields: []string{"password"}}
+
+	got := string(formatBody("application/json", body, cfg))
+
+	if !strings.Contains(got, `"user": "alice"`) {
+		t.Errorf("formatBody() = %q, want to contain user field", got)
+	}
+	if !strings.Contains(got, `"password": "***"`) {
+		t.Errorf("formatBody() = %q, want password redacted", got)
+	}
+}
+
+func TestFormatBodyJSONInvalidReturnsAsIs(t *testing.T) {
+	body := []byte(`not json`)
+	got := formatBody("application/json", body, &Config{})
+	if string(got) != "not json" {
+		t.Errorf("formatBody() = %q, want body unchanged on parse error", got)
+	}
+}
+
+func TestFormatBodyBinarySummarizes(t *testing.T) {
+	body := []byte{0x01, 0x02, 0x03}
+	got := string(formatBody("image/png", body, &Config{}))
+	want := "Content-Type: image/png, Size: 3 bytes"
+	if got != want {
+		t.Errorf("formatBody() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatBodyEmptyReturnsEmpty(t *testing.T) {
+	got := formatBody("application/json", nil, &Config{})
+	if len(got) != 0 {
+		t.Errorf("formatBody(nil) = %q, want empty", got)
+	}
+}
+
+func TestFormatBodyXMLPrettyPrints(t *testing.T) {
+	body := []byte(`<root><a>1</a></root>`)
+	got := string(formatBody("application/xml", body, &Config{}))
+	if !strings.Contains(got, "\n") {
+		t.Errorf("formatBody() = %q, want indented XML", got)
+	}
+}
+
+func TestFormatBodyFormRedactsFields(t *testing.T) {
+	body := []byte("user=alice&password=hunter2")
+	cfg := &Config{RedactJSONFields: []string{"password"}}
+
+	got := string(formatBody("application/x-www-form-urlencoded", body, cfg))
+
+	if !strings.Contains(got, "user: alice") {
+		t.Errorf("formatBody() = %q, want user field present", got)
+	}
+	if !strings.Contains(got, "password: ***") {
+		t.Errorf("formatBody() = %q, want password redacted", got)
+	}
+}
+
+func TestFormatBodyUnknownTypePassesThrough(t *testing.T) {
+	body := []byte("plain text")
+	got := formatBody("text/plain", body, &Config{})
+	if string(got) != "plain text" {
+		t.Errorf("formatBody() = %q, want unchanged", got)
+	}
+}
@@ -0,0 +1,25 @@
+package httplog
+
+import "context"
+
+// reqIDKeyType - тип ключа context для reqID, чтобы избежать коллизий с другими пакетами
+type reqIDKeyType struct{}
+
+var reqIDKey reqIDKeyType
+
+// HeaderReqID - наименование HTTP заголовка, используемого для передачи reqID
+const HeaderReqID = "X-Request-ID"
+
+// WithReqID - добавляет reqID в context
+func WithReqID(ctx context.Context, reqID uint64) context.Context {
+	return context.WithValue(ctx, reqIDKey, reqID)
+}
+
+// ReqIDFromContext - читает reqID из context, если он был установлен Middleware
+func ReqIDFromContext(ctx context.Context) (uint64, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	v, ok := ctx.Value(reqIDKey).(uint64)
+	return v, ok
+}
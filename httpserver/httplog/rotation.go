@@ -0,0 +1,35 @@
+package httplog
+
+import (
+	"fmt"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// newRotatingWriter - создает ротируемый writer логирования HTTP вызовов поверх
+// lumberjack.Logger в соответствии с параметрами ротации из cfg
+func newRotatingWriter(cfg *Config) *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   cfg.FileName,
+		MaxSize:    cfg.MaxLogSizeMB,
+		MaxBackups: cfg.MaxBackups,
+		MaxAge:     cfg.MaxAgeDays,
+		Compress:   cfg.UseGzip,
+	}
+}
+
+// truncateBody - обрезает тело запроса/ответа до maxBytes, добавляя маркер
+// обрезки. maxBytes <= 0 означает отсутствие ограничения
+func truncateBody(body []byte, maxBytes int) []byte {
+	if maxBytes <= 0 || len(body) <= maxBytes {
+		return body
+	}
+
+	truncated := len(body) - maxBytes
+	marker := []byte(fmt.Sprintf("...[truncated %d bytes]", truncated))
+
+	out := make([]byte, 0, maxBytes+len(marker))
+	out = append(out, body[:maxBytes]...)
+	out = append(out, marker...)
+	return out
+}
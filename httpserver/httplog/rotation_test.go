@@ -0,0 +1,54 @@
+package httplog
+
+import "testing"
+
+func TestNewRotatingWriter(t *testing.T) {
+	cfg := &Config{
+		FileName:     "/tmp/test.log",
+		MaxLogSizeMB: 10,
+		MaxBackups:   3,
+		MaxAgeDays:   7,
+		UseGzip:      true,
+	}
+
+	w := newRotatingWriter(cfg)
+
+	if w.Filename != cfg.FileName {
+		t.Errorf("Filename = %q, want %q", w.Filename, cfg.FileName)
+	}
+	if w.MaxSize != cfg.MaxLogSizeMB {
+		t.Errorf("MaxSize = %d, want %d", w.MaxSize, cfg.MaxLogSizeMB)
+	}
+	if w.MaxBackups != cfg.MaxBackups {
+		t.Errorf("MaxBackups = %d, want %d", w.MaxBackups, cfg.MaxBackups)
+	}
+	if w.MaxAge != cfg.MaxAgeDays {
+		t.Errorf("MaxAge = %d, want %d", w.MaxAge, cfg.MaxAgeDays)
+	}
+	if w.Compress != cfg.UseGzip {
+		t.Errorf("Compress = %v, want %v", w.Compress, cfg.UseGzip)
+	}
+}
+
+func TestTruncateBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		maxBytes int
+		want     string
+	}{
+		{"no limit", "hello world", 0, "hello world"},
+		{"under limit unchanged", "hello", 10, "hello"},
+		{"exact limit unchanged", "hello", 5, "hello"},
+		{"over limit truncated with marker", "hello world", 5, "hello...[truncated 6 bytes]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateBody([]byte(tt.body), tt.maxBytes)
+			if string(got) != tt.want {
+				t.Errorf("truncateBody(%q, %d) = %q, want %q", tt.body, tt.maxBytes, got, tt.want)
+			}
+		})
+	}
+}
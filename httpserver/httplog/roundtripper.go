@@ -0,0 +1,140 @@
+package httplog
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// countingReadCloser - оборачивает io.ReadCloser, считая прочитанные байты и
+// вызывая onClose ровно один раз при закрытии потока
+type countingReadCloser struct {
+	inner   io.ReadCloser
+	n       int64
+	onClose func(n int64)
+	closed  bool
+}
+
+// Read - считает прочитанные байты и пробрасывает чтение во вложенный поток
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.inner.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Close - закрывает вложенный поток и один раз вызывает onClose с итоговым
+// числом прочитанных байт
+func (c *countingReadCloser) Close() error {
+	err := c.inner.Close()
+	if !c.closed {
+		c.closed = true
+		if c.onClose != nil {
+			c.onClose(c.n)
+		}
+	}
+	return err
+}
+
+// headerSize - приблизительный размер заголовков в байтах, как если бы они
+// были переданы по проводу в формате "Имя: значение\r\n"
+func headerSize(header http.Header) int64 {
+	var size int64
+	for name, values := range header {
+		for _, v := range values {
+			size += int64(len(name) + len(v) + 4) // ": " + "\r\n"
+		}
+	}
+	return size
+}
+
+// headerSizeFlat - то же самое для уже сведенных в map[string]string заголовков
+func headerSizeFlat(header map[string]string) int64 {
+	var size int64
+	for name, v := range header {
+		size += int64(len(name) + len(v) + 4)
+	}
+	return size
+}
+
+// RoundTripper - оборачивает http.RoundTripper учетом трафика: считает размер
+// заголовков/тел запроса и ответа и длительность вызова, и записывает
+// получившуюся RequestStat в Stats по аналогии с учетом трафика в HTTP
+// клиенте git-lfs
+type RoundTripper struct {
+	Next   http.RoundTripper           // базовый транспорт; http.DefaultTransport, если не задан
+	Stats  *Stats                      // коллектор статистики
+	Bucket func(*http.Request) string  // выбор имени бакета; по умолчанию req.URL.Host
+}
+
+// transport - возвращает базовый транспорт
+func (rt *RoundTripper) transport() http.RoundTripper {
+	if rt.Next != nil {
+		return rt.Next
+	}
+	return http.DefaultTransport
+}
+
+// bucketFor - возвращает имя бакета для запроса
+func (rt *RoundTripper) bucketFor(req *http.Request) string {
+	if rt.Bucket != nil {
+		return rt.Bucket(req)
+	}
+	return req.URL.Host
+}
+
+// RoundTrip - выполняет запрос через базовый транспорт, оборачивая тела
+// запроса/ответа счетчиками байт и записывая статистику после того, как
+// вызывающая сторона закроет тело ответа (если тела нет - сразу)
+// =====================================================================
+func (rt *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	reqHeaderSize := headerSize(req.Header)
+
+	var reqBody *countingReadCloser
+	if req.Body != nil {
+		reqBody = &countingReadCloser{inner: req.Body}
+		req.Body = reqBody
+	}
+
+	resp, err := rt.transport().RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	var reqBodySize int64
+	if reqBody != nil {
+		reqBodySize = reqBody.n
+	}
+
+	bucket := rt.bucketFor(req)
+	respHeaderSize := headerSize(resp.Header)
+
+	record := func(respBodySize int64, stop time.Time) {
+		if rt.Stats == nil {
+			return
+		}
+		rt.Stats.Record(RequestStat{
+			Bucket:             bucket,
+			RequestHeaderSize:  reqHeaderSize,
+			RequestBodySize:    reqBodySize,
+			ResponseHeaderSize: respHeaderSize,
+			ResponseBodySize:   respBodySize,
+			Start:              start,
+			Stop:               stop,
+		})
+	}
+
+	if resp.Body == nil {
+		record(0, time.Now())
+		return resp, nil
+	}
+
+	resp.Body = &countingReadCloser{
+		inner: resp.Body,
+		onClose: func(n int64) {
+			record(n, time.Now())
+		},
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,136 @@
+package httplog
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// fakeRoundTripper - RoundTripper, возвращающий заранее заданный ответ
+type fakeRoundTripper struct {
+	resp *http.Response
+	err  error
+}
+
+func (f *fakeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+	}
+	return f.resp, f.err
+}
+
+func TestHeaderSize(t *testing.T) {
+	h := http.Header{"X-A": []string{"1"}, "X-B": []string{"22", "333"}}
+	want := int64(len("X-A")+len("1")+4) + int64(len("X-B")+len("22")+4) + int64(len("X-B")+len("333")+4)
+	if got := headerSize(h); got != want {
+		t.Errorf("headerSize() = %d, want %d", got, want)
+	}
+}
+
+func TestHeaderSizeFlat(t *testing.T) {
+	h := map[string]string{"X-A": "1"}
+	want := int64(len("X-A") + len("1") + 4)
+	if got := headerSizeFlat(h); got != want {
+		t.Errorf("headerSizeFlat() = %d, want %d", got, want)
+	}
+}
+
+func TestCountingReadCloserCountsBytesAndCallsOnCloseOnce(t *testing.T) {
+	var closedWith int64 = -1
+	var closeCalls int
+
+	c := &countingReadCloser{
+		inner: ioutil.NopCloser(strings.NewReader("hello world")),
+		onClose: func(n int64) {
+			closedWith = n
+			closeCalls++
+		},
+	}
+
+	if _, err := io.Copy(io.Discard, c); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := c.Close(); err != nil { // повторный Close не должен паниковать или вызывать onClose снова
+		t.Fatalf("second Close() error = %v", err)
+	}
+
+	if closedWith != int64(len("hello world")) {
+		t.Errorf("onClose called with n = %d, want %d", closedWith, len("hello world"))
+	}
+	if closeCalls != 1 {
+		t.Errorf("onClose called %d times, want 1", closeCalls)
+	}
+}
+
+func TestRoundTripperRecordsStatsAfterBodyRead(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"X-Resp": []string{"v"}},
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte("response body"))),
+	}
+
+	stats := NewStats()
+	rt := &RoundTripper{Next: &fakeRoundTripper{resp: resp}, Stats: stats}
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/api", strings.NewReader("request body"))
+
+	got, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+
+	if _, err := io.Copy(io.Discard, got.Body); err != nil {
+		t.Fatalf("reading response body error = %v", err)
+	}
+	_ = got.Body.Close()
+
+	summaries := stats.snapshot()
+	if len(summaries) != 1 {
+		t.Fatalf("snapshot() returned %d buckets, want 1", len(summaries))
+	}
+	if summaries[0].Bucket != "example.com" {
+		t.Errorf("Bucket = %q, want %q (default bucket is req.URL.Host)", summaries[0].Bucket, "example.com")
+	}
+	if summaries[0].ReqBodyBytes != int64(len("request body")) {
+		t.Errorf("ReqBodyBytes = %d, want %d", summaries[0].ReqBodyBytes, len("request body"))
+	}
+	if summaries[0].RespBodyBytes != int64(len("response body")) {
+		t.Errorf("RespBodyBytes = %d, want %d", summaries[0].RespBodyBytes, len("response body"))
+	}
+}
+
+func TestRoundTripperCustomBucket(t *testing.T) {
+	resp := &http.Response{StatusCode: 200, Header: http.Header{}, Body: nil}
+	stats := NewStats()
+	rt := &RoundTripper{
+		Next:   &fakeRoundTripper{resp: resp},
+		Stats:  stats,
+		Bucket: func(req *http.Request) string { return "custom" },
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/api", nil)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+
+	summaries := stats.snapshot()
+	if len(summaries) != 1 || summaries[0].Bucket != "custom" {
+		t.Fatalf("snapshot() = %+v, want single bucket named \"custom\"", summaries)
+	}
+}
+
+func TestRoundTripperPropagatesError(t *testing.T) {
+	wantErr := io.ErrUnexpectedEOF
+	rt := &RoundTripper{Next: &fakeRoundTripper{err: wantErr}}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/api", nil)
+	if _, err := rt.RoundTrip(req); err != wantErr {
+		t.Errorf("RoundTrip() error = %v, want %v", err, wantErr)
+	}
+}
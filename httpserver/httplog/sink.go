@@ -0,0 +1,123 @@
+package httplog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	myerror "github.com/romapres2010/httpserver/error"
+	mylog "github.com/romapres2010/httpserver/log"
+)
+
+// SinkType - тип получателя структурных записей логирования
+type SinkType string
+
+// Допустимые значения SinkType
+const (
+	SinkText SinkType = "text" // человекочитаемый дамп, как раньше
+	SinkJSON SinkType = "json" // одна JSON запись на строку - для ELK/Loki
+	SinkZap  SinkType = "zap"  // структурное логирование через zap
+)
+
+// textSink - получатель, пишущий записи в человекочитаемом формате: строки
+// BEGIN/END в стиле старого формата логирования, между ними поля записи,
+// заголовки и тела (не дамп httputil, как раньше)
+type textSink struct {
+	w io.Writer
+}
+
+// newTextSink - создает новый textSink
+func newTextSink(w io.Writer) *textSink {
+	return &textSink{w: w}
+}
+
+// Write - записывает одну запись в человекочитаемом формате
+func (s *textSink) Write(entry Entry) error {
+	ts := entry.Timestamp.Format("2006-01-02 15:04:05.000")
+
+	fmt.Fprintf(s.w, "'%s' %s '%v' BEGIN ==================================================================== \n", ts, entry.Direction, entry.ReqID)
+	fmt.Fprintf(s.w, "trace_id=%s span_id=%s method=%s url=%s status=%v remote_addr=%s duration_ms=%v\n",
+		entry.TraceID, entry.SpanID, entry.Method, entry.URL, entry.Status, entry.RemoteAddr, entry.DurationMS)
+
+	for k, v := range entry.ReqHeaders {
+		fmt.Fprintf(s.w, "req_header %s: %s\n", k, v)
+	}
+	for k, v := range entry.RespHeaders {
+		fmt.Fprintf(s.w, "resp_header %s: %s\n", k, v)
+	}
+	if len(entry.ReqBody) > 0 {
+		fmt.Fprintf(s.w, "req_body:\n%s\n", string(entry.ReqBody))
+	}
+	if len(entry.RespBody) > 0 {
+		fmt.Fprintf(s.w, "resp_body:\n%s\n", string(entry.RespBody))
+	}
+
+	fmt.Fprintf(s.w, "'%s' %s '%v' END ==================================================================== \n", ts, entry.Direction, entry.ReqID)
+	return nil
+}
+
+// Close - textSink не владеет writer-ом, закрывать нечего
+func (s *textSink) Close() error {
+	return nil
+}
+
+// jsonEntry - плоское представление Entry для сериализации в JSON одной строкой
+type jsonEntry struct {
+	Timestamp   string            `json:"timestamp"`
+	Direction   string            `json:"direction"`
+	ReqID       uint64            `json:"req_id"`
+	TraceID     string            `json:"trace_id,omitempty"`
+	SpanID      string            `json:"span_id,omitempty"`
+	Method      string            `json:"method,omitempty"`
+	URL         string            `json:"url,omitempty"`
+	Status      int               `json:"status,omitempty"`
+	RemoteAddr  string            `json:"remote_addr,omitempty"`
+	DurationMS  int64             `json:"duration_ms,omitempty"`
+	ReqHeaders  map[string]string `json:"req_headers,omitempty"`
+	RespHeaders map[string]string `json:"resp_headers,omitempty"`
+	ReqBody     string            `json:"req_body,omitempty"`
+	RespBody    string            `json:"resp_body,omitempty"`
+}
+
+// jsonSink - получатель, пишущий по одной JSON записи на строку (JSONL)
+type jsonSink struct {
+	w   io.Writer
+	enc *json.Encoder
+}
+
+// newJSONSink - создает новый jsonSink
+func newJSONSink(w io.Writer) *jsonSink {
+	return &jsonSink{w: w, enc: json.NewEncoder(w)}
+}
+
+// Write - сериализует запись в JSON и пишет одну строку
+func (s *jsonSink) Write(entry Entry) error {
+	je := jsonEntry{
+		Timestamp:   entry.Timestamp.Format("2006-01-02T15:04:05.000Z07:00"),
+		Direction:   entry.Direction,
+		ReqID:       entry.ReqID,
+		TraceID:     entry.TraceID,
+		SpanID:      entry.SpanID,
+		Method:      entry.Method,
+		URL:         entry.URL,
+		Status:      entry.Status,
+		RemoteAddr:  entry.RemoteAddr,
+		DurationMS:  entry.DurationMS,
+		ReqHeaders:  entry.ReqHeaders,
+		RespHeaders: entry.RespHeaders,
+		ReqBody:     string(entry.ReqBody),
+		RespBody:    string(entry.RespBody),
+	}
+
+	if err := s.enc.Encode(je); err != nil {
+		myerr := myerror.New("8021", "Error encode HTTP log entry to JSON", "json.Encode", "")
+		mylog.PrintfErrorStd(fmt.Sprintf("%+v", myerr))
+		return myerr
+	}
+	return nil
+}
+
+// Close - jsonSink не владеет writer-ом, закрывать нечего
+func (s *jsonSink) Close() error {
+	return nil
+}
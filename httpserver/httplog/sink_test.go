@@ -0,0 +1,94 @@
+package httplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTextSinkWrite(t *testing.T) {
+	var buf bytes.Buffer
+	s := newTextSink(&buf)
+
+	entry := Entry{
+		Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Direction: "in_request",
+		ReqID:     42,
+		Method:    "GET",
+		URL:       "/api/users",
+	}
+
+	if err := s.Write(entry); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "in_request") || !strings.Contains(out, "42") {
+		t.Errorf("Write() output missing direction/reqID: %s", out)
+	}
+	if !strings.Contains(out, "method=GET") {
+		t.Errorf("Write() output missing method: %s", out)
+	}
+}
+
+func TestJSONSinkWrite(t *testing.T) {
+	var buf bytes.Buffer
+	s := newJSONSink(&buf)
+
+	entry := Entry{
+		Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Direction: "out_response",
+		ReqID:     7,
+		Status:    200,
+		ReqBody:   []byte(`{"a":1}`),
+	}
+
+	if err := s.Write(entry); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var je jsonEntry
+	if err := json.Unmarshal(buf.Bytes(), &je); err != nil {
+		t.Fatalf("output is not valid JSON: %v, got: %s", err, buf.String())
+	}
+	if je.Direction != "out_response" || je.ReqID != 7 || je.Status != 200 {
+		t.Errorf("decoded jsonEntry = %+v, want direction=out_response reqID=7 status=200", je)
+	}
+	if je.ReqBody != `{"a":1}` {
+		t.Errorf("decoded ReqBody = %q, want %q", je.ReqBody, `{"a":1}`)
+	}
+}
+
+func TestNewSinkDefault(t *testing.T) {
+	var buf bytes.Buffer
+	sink, err := newSink(&Config{}, &buf)
+	if err != nil {
+		t.Fatalf("newSink() error = %v", err)
+	}
+	if _, ok := sink.(*textSink); !ok {
+		t.Errorf("newSink() with empty SinkType = %T, want *textSink", sink)
+	}
+}
+
+func TestNewSinkJSON(t *testing.T) {
+	var buf bytes.Buffer
+	sink, err := newSink(&Config{SinkType: SinkJSON}, &buf)
+	if err != nil {
+		t.Fatalf("newSink() error = %v", err)
+	}
+	if _, ok := sink.(*jsonSink); !ok {
+		t.Errorf("newSink() with SinkJSON = %T, want *jsonSink", sink)
+	}
+}
+
+func TestNewSinkNilWriterDiscards(t *testing.T) {
+	sink, err := newSink(&Config{SinkType: SinkJSON}, nil)
+	if err != nil {
+		t.Fatalf("newSink() error = %v", err)
+	}
+	if err := sink.Write(Entry{ReqID: 1}); err != nil {
+		t.Errorf("Write() to discard sink error = %v", err)
+	}
+}
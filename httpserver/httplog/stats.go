@@ -0,0 +1,180 @@
+package httplog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxSamplesPerBucket - максимальное число длительностей, хранимых на бакет
+// для расчета перцентилей. При превышении самые старые значения вытесняются
+const maxSamplesPerBucket = 10000
+
+// RequestStat - статистика передачи данных одного запроса, как входящего, так
+// и исходящего. По аналогии с учетом трафика в HTTP клиенте git-lfs
+type RequestStat struct {
+	ReqID              uint64    // номер запроса, присвоенный Logger-ом
+	Bucket             string    // имя группы, например хост для исходящих запросов или маршрут для входящих
+	RequestHeaderSize  int64     // размер заголовков запроса в байтах
+	RequestBodySize    int64     // размер тела запроса в байтах
+	ResponseHeaderSize int64     // размер заголовков ответа в байтах
+	ResponseBodySize   int64     // размер тела ответа в байтах
+	Start              time.Time // момент начала обработки запроса
+	Stop               time.Time // момент завершения обработки запроса
+	DurationMS         int64     // длительность обработки в миллисекундах
+}
+
+// bucketStats - агрегированная статистика одного бакета
+type bucketStats struct {
+	mu sync.Mutex
+
+	count             int64
+	reqHeaderBytes    int64
+	reqBodyBytes      int64
+	respHeaderBytes   int64
+	respBodyBytes     int64
+	durationsMS       []int64
+	durationsMSCursor int
+}
+
+// Stats - коллектор статистики передачи данных HTTP запросов/ответов,
+// сгруппированный по именованным бакетам (например хост для исходящих
+// вызовов или маршрут для входящих)
+type Stats struct {
+	mu      sync.Mutex
+	buckets map[string]*bucketStats
+}
+
+// NewStats - создает новый Stats
+func NewStats() *Stats {
+	return &Stats{
+		buckets: make(map[string]*bucketStats),
+	}
+}
+
+// Record - записывает статистику одного запроса в соответствующий бакет
+func (s *Stats) Record(stat RequestStat) {
+	if stat.DurationMS == 0 && !stat.Start.IsZero() && !stat.Stop.IsZero() {
+		stat.DurationMS = int64(stat.Stop.Sub(stat.Start) / time.Millisecond)
+	}
+
+	bucket := stat.Bucket
+	if bucket == "" {
+		bucket = "default"
+	}
+
+	s.mu.Lock()
+	b, ok := s.buckets[bucket]
+	if !ok {
+		b = &bucketStats{}
+		s.buckets[bucket] = b
+	}
+	s.mu.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.count++
+	b.reqHeaderBytes += stat.RequestHeaderSize
+	b.reqBodyBytes += stat.RequestBodySize
+	b.respHeaderBytes += stat.ResponseHeaderSize
+	b.respBodyBytes += stat.ResponseBodySize
+
+	if len(b.durationsMS) < maxSamplesPerBucket {
+		b.durationsMS = append(b.durationsMS, stat.DurationMS)
+	} else {
+		// кольцевой буфер - вытесняем самые старые сэмплы
+		b.durationsMS[b.durationsMSCursor] = stat.DurationMS
+		b.durationsMSCursor = (b.durationsMSCursor + 1) % maxSamplesPerBucket
+	}
+}
+
+// bucketSummary - сводка по одному бакету для печати и экспорта метрик
+type bucketSummary struct {
+	Bucket          string
+	Count           int64
+	ReqHeaderBytes  int64
+	ReqBodyBytes    int64
+	RespHeaderBytes int64
+	RespBodyBytes   int64
+	MinMS           int64
+	MaxMS           int64
+	AvgMS           float64
+	P95MS           int64
+}
+
+// snapshot - возвращает сводки по всем бакетам, отсортированные по имени
+func (s *Stats) snapshot() []bucketSummary {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.buckets))
+	buckets := make(map[string]*bucketStats, len(s.buckets))
+	for name, b := range s.buckets {
+		names = append(names, name)
+		buckets[name] = b
+	}
+	s.mu.Unlock()
+
+	sort.Strings(names)
+
+	summaries := make([]bucketSummary, 0, len(names))
+	for _, name := range names {
+		b := buckets[name]
+
+		b.mu.Lock()
+		durations := make([]int64, len(b.durationsMS))
+		copy(durations, b.durationsMS)
+		summary := bucketSummary{
+			Bucket:          name,
+			Count:           b.count,
+			ReqHeaderBytes:  b.reqHeaderBytes,
+			ReqBodyBytes:    b.reqBodyBytes,
+			RespHeaderBytes: b.respHeaderBytes,
+			RespBodyBytes:   b.respBodyBytes,
+		}
+		b.mu.Unlock()
+
+		summary.MinMS, summary.MaxMS, summary.AvgMS, summary.P95MS = summarizeDurations(durations)
+		summaries = append(summaries, summary)
+	}
+
+	return summaries
+}
+
+// summarizeDurations - возвращает min/max/avg/p95 по набору длительностей в миллисекундах
+func summarizeDurations(durations []int64) (min, max int64, avg float64, p95 int64) {
+	if len(durations) == 0 {
+		return 0, 0, 0, 0
+	}
+
+	sorted := make([]int64, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	min = sorted[0]
+	max = sorted[len(sorted)-1]
+
+	var sum int64
+	for _, d := range sorted {
+		sum += d
+	}
+	avg = float64(sum) / float64(len(sorted))
+
+	idx := int(float64(len(sorted))*0.95 + 0.5)
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	p95 = sorted[idx]
+
+	return min, max, avg, p95
+}
+
+// DumpStats - печатает агрегированную статистику по всем бакетам
+func (s *Stats) DumpStats(w io.Writer) {
+	for _, summary := range s.snapshot() {
+		fmt.Fprintf(w, "bucket=%s count=%d min_ms=%d max_ms=%d avg_ms=%.1f p95_ms=%d req_header_bytes=%d req_body_bytes=%d resp_header_bytes=%d resp_body_bytes=%d\n",
+			summary.Bucket, summary.Count, summary.MinMS, summary.MaxMS, summary.AvgMS, summary.P95MS,
+			summary.ReqHeaderBytes, summary.ReqBodyBytes, summary.RespHeaderBytes, summary.RespBodyBytes)
+	}
+}
@@ -0,0 +1,98 @@
+package httplog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatsRecordAggregatesByBucket(t *testing.T) {
+	s := NewStats()
+
+	s.Record(RequestStat{Bucket: "api", RequestHeaderSize: 10, RequestBodySize: 20, DurationMS: 100})
+	s.Record(RequestStat{Bucket: "api", RequestHeaderSize: 5, RequestBodySize: 15, DurationMS: 200})
+	s.Record(RequestStat{Bucket: "other", DurationMS: 50})
+
+	summaries := s.snapshot()
+	if len(summaries) != 2 {
+		t.Fatalf("snapshot() returned %d buckets, want 2", len(summaries))
+	}
+
+	api := summaries[0]
+	if api.Bucket != "api" {
+		t.Fatalf("summaries[0].Bucket = %q, want %q", api.Bucket, "api")
+	}
+	if api.Count != 2 {
+		t.Errorf("api.Count = %d, want 2", api.Count)
+	}
+	if api.ReqHeaderBytes != 15 || api.ReqBodyBytes != 35 {
+		t.Errorf("api bytes = (%d, %d), want (15, 35)", api.ReqHeaderBytes, api.ReqBodyBytes)
+	}
+	if api.MinMS != 100 || api.MaxMS != 200 {
+		t.Errorf("api min/max = (%d, %d), want (100, 200)", api.MinMS, api.MaxMS)
+	}
+}
+
+func TestStatsRecordDefaultsEmptyBucket(t *testing.T) {
+	s := NewStats()
+	s.Record(RequestStat{DurationMS: 1})
+
+	summaries := s.snapshot()
+	if len(summaries) != 1 || summaries[0].Bucket != "default" {
+		t.Fatalf("snapshot() = %+v, want single bucket named \"default\"", summaries)
+	}
+}
+
+func TestStatsRecordComputesDurationFromStartStop(t *testing.T) {
+	s := NewStats()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	stop := start.Add(250 * time.Millisecond)
+
+	s.Record(RequestStat{Bucket: "api", Start: start, Stop: stop})
+
+	summaries := s.snapshot()
+	if summaries[0].MinMS != 250 {
+		t.Errorf("computed DurationMS = %d, want 250", summaries[0].MinMS)
+	}
+}
+
+func TestSummarizeDurationsEmpty(t *testing.T) {
+	min, max, avg, p95 := summarizeDurations(nil)
+	if min != 0 || max != 0 || avg != 0 || p95 != 0 {
+		t.Errorf("summarizeDurations(nil) = (%d, %d, %v, %d), want all zero", min, max, avg, p95)
+	}
+}
+
+func TestSummarizeDurations(t *testing.T) {
+	durations := []int64{10, 20, 30, 40, 100}
+
+	min, max, avg, p95 := summarizeDurations(durations)
+
+	if min != 10 {
+		t.Errorf("min = %d, want 10", min)
+	}
+	if max != 100 {
+		t.Errorf("max = %d, want 100", max)
+	}
+	if avg != 40 {
+		t.Errorf("avg = %v, want 40", avg)
+	}
+	if p95 != 100 {
+		t.Errorf("p95 = %d, want 100 (highest sample)", p95)
+	}
+}
+
+func TestStatsRecordEvictsOldestSampleWhenBucketFull(t *testing.T) {
+	s := NewStats()
+
+	for i := 0; i < maxSamplesPerBucket+5; i++ {
+		s.Record(RequestStat{Bucket: "api", DurationMS: int64(i)})
+	}
+
+	summaries := s.snapshot()
+	if summaries[0].Count != int64(maxSamplesPerBucket+5) {
+		t.Errorf("Count = %d, want %d (count keeps growing even as samples are evicted)", summaries[0].Count, maxSamplesPerBucket+5)
+	}
+	if summaries[0].MaxMS != int64(maxSamplesPerBucket+4) {
+		t.Errorf("MaxMS = %d, want %d (latest sample must still be present)", summaries[0].MaxMS, maxSamplesPerBucket+4)
+	}
+}
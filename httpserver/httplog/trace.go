@@ -0,0 +1,46 @@
+package httplog
+
+import "context"
+
+// traceIDKeyType - тип ключа context для trace_id, чтобы избежать коллизий с другими пакетами
+type traceIDKeyType struct{}
+
+// spanIDKeyType - тип ключа context для span_id, чтобы избежать коллизий с другими пакетами
+type spanIDKeyType struct{}
+
+var (
+	traceIDKey traceIDKeyType
+	spanIDKey  spanIDKeyType
+)
+
+// WithTraceID - добавляет trace_id в context для последующего логирования
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
+}
+
+// WithSpanID - добавляет span_id в context для последующего логирования
+func WithSpanID(ctx context.Context, spanID string) context.Context {
+	return context.WithValue(ctx, spanIDKey, spanID)
+}
+
+// traceIDFromContext - читает trace_id из context, если он был установлен
+func traceIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if v, ok := ctx.Value(traceIDKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// spanIDFromContext - читает span_id из context, если он был установлен
+func spanIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if v, ok := ctx.Value(spanIDKey).(string); ok {
+		return v
+	}
+	return ""
+}
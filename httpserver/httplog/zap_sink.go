@@ -0,0 +1,69 @@
+package httplog
+
+import (
+	"io"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// zapSink - получатель, пишущий записи через структурный логгер zap
+type zapSink struct {
+	logger *zap.Logger
+}
+
+// newZapSink - создает новый zapSink, пишущий в writer. Если writer == nil,
+// создается продуктовый логгер zap по умолчанию (stderr)
+func newZapSink(writer io.Writer) (*zapSink, error) {
+	if writer == nil {
+		l, err := zap.NewProduction()
+		if err != nil {
+			return nil, err
+		}
+		return &zapSink{logger: l}, nil
+	}
+
+	encoderCfg := zap.NewProductionEncoderConfig()
+	encoderCfg.TimeKey = "timestamp"
+	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderCfg), zapcore.AddSync(writer), zap.NewAtomicLevelAt(zapcore.InfoLevel))
+	return &zapSink{logger: zap.New(core)}, nil
+}
+
+// Write - пишет одну запись в виде структурных полей zap
+func (s *zapSink) Write(entry Entry) error {
+	fields := []zapcore.Field{
+		zap.Time("timestamp", entry.Timestamp),
+		zap.String("direction", entry.Direction),
+		zap.Uint64("req_id", entry.ReqID),
+		zap.String("trace_id", entry.TraceID),
+		zap.String("span_id", entry.SpanID),
+		zap.String("method", entry.Method),
+		zap.String("url", entry.URL),
+		zap.Int("status", entry.Status),
+		zap.String("remote_addr", entry.RemoteAddr),
+		zap.Int64("duration_ms", entry.DurationMS),
+	}
+
+	if len(entry.ReqHeaders) > 0 {
+		fields = append(fields, zap.Any("req_headers", entry.ReqHeaders))
+	}
+	if len(entry.RespHeaders) > 0 {
+		fields = append(fields, zap.Any("resp_headers", entry.RespHeaders))
+	}
+	if len(entry.ReqBody) > 0 {
+		fields = append(fields, zap.ByteString("req_body", entry.ReqBody))
+	}
+	if len(entry.RespBody) > 0 {
+		fields = append(fields, zap.ByteString("resp_body", entry.RespBody))
+	}
+
+	s.logger.Info("http", fields...)
+	return nil
+}
+
+// Close - сбрасывает буфер zap логгера
+func (s *zapSink) Close() error {
+	return s.logger.Sync()
+}
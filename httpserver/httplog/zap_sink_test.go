@@ -0,0 +1,62 @@
+package httplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestZapSinkWrite(t *testing.T) {
+	var buf bytes.Buffer
+	s, err := newZapSink(&buf)
+	if err != nil {
+		t.Fatalf("newZapSink() error = %v", err)
+	}
+
+	entry := Entry{
+		Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Direction: "in_request",
+		ReqID:     9,
+		Method:    "POST",
+		Status:    201,
+	}
+
+	if err := s.Write(entry); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		t.Fatalf("output is not valid JSON: %v, got: %s", err, buf.String())
+	}
+
+	if fields["direction"] != "in_request" {
+		t.Errorf("fields[direction] = %v, want in_request", fields["direction"])
+	}
+	if fields["req_id"] != float64(9) {
+		t.Errorf("fields[req_id] = %v, want 9", fields["req_id"])
+	}
+	if fields["method"] != "POST" {
+		t.Errorf("fields[method] = %v, want POST", fields["method"])
+	}
+}
+
+func TestZapSinkWritesToConfiguredWriter(t *testing.T) {
+	var buf bytes.Buffer
+	s, err := newZapSink(&buf)
+	if err != nil {
+		t.Fatalf("newZapSink() error = %v", err)
+	}
+
+	if err := s.Write(Entry{Direction: "out_request"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if buf.Len() == 0 {
+		t.Error("zapSink did not write to the configured writer")
+	}
+}